@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+var (
+	brokerDescribeOutput string
+	brokerDescribeAll    bool
+	brokerAlterSet       []string
+	brokerAlterDelete    []string
+	brokerAlterDryRun    bool
+)
+
+// brokerDescription is the describe output of a broker
+type brokerDescription struct {
+	ID      int32
+	Host    string
+	Port    int32
+	Rack    string `json:",omitempty"`
+	Configs []topicConfig
+}
+
+var brokerCmd = &cobra.Command{
+	Use:   "broker",
+	Short: "Inspect and configure brokers",
+}
+
+var describeBrokerCmd = &cobra.Command{
+	Use:   "describe <broker-id>",
+	Short: "Show a broker's configs and where they come from",
+	Long: `Show the configs of a broker with their source: dynamic for the broker, dynamic
+default for the cluster, static from server.properties. Defaults are hidden
+unless --all is set. Sensitive values are never shown.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseBrokerID(args[0])
+		if err != nil {
+			return err
+		}
+		if brokerDescribeOutput != OutputTable && brokerDescribeOutput != OutputJSON {
+			return fmt.Errorf("unsupported --output %q (expected %s or %s)", brokerDescribeOutput, OutputTable, OutputJSON)
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		broker, err := adminClient.Broker(ctx, id)
+		if err != nil {
+			return err
+		}
+		configs, err := adminClient.DescribeBrokerConfig(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get configs of broker %d: %w", id, err)
+		}
+
+		desc := buildBrokerDescription(broker, configs, brokerDescribeAll)
+		if brokerDescribeOutput == OutputJSON {
+			return writeJSON(desc)
+		}
+		printBrokerDescription(desc)
+		return nil
+	},
+}
+
+var alterBrokerConfigCmd = &cobra.Command{
+	Use:   "alter-config <broker-id>",
+	Short: "Set or delete dynamic broker configs",
+	Long: `Incrementally set (--set key=value) or delete (--delete key) dynamic configs of
+a broker, applied without a restart. Deleted configs fall back to the cluster
+default or server.properties. Use --dry-run to only validate the changes.`,
+	Example: `  kafka-cli broker alter-config 1 --set log.cleaner.threads=2
+  kafka-cli broker alter-config 1 --delete log.cleaner.threads --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseBrokerID(args[0])
+		if err != nil {
+			return err
+		}
+		alterations, err := parseAlterConfigs(brokerAlterSet, brokerAlterDelete)
+		if err != nil {
+			return err
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		if _, err := adminClient.Broker(ctx, id); err != nil {
+			return err
+		}
+		if err := adminClient.AlterBrokerConfig(ctx, id, alterations, brokerAlterDryRun); err != nil {
+			return fmt.Errorf("failed to alter configs of broker %d: %w", id, err)
+		}
+
+		for _, a := range alterations {
+			if a.Op == kadm.DeleteConfig {
+				color.Yellow(" - %s (reset)", a.Name)
+			} else {
+				color.Yellow(" - %s=%s", a.Name, *a.Value)
+			}
+		}
+		if brokerAlterDryRun {
+			color.Green("✅ Config changes for broker %d are valid (dry run, nothing applied)", id)
+		} else {
+			color.Green("✅ Updated configs of broker %d", id)
+		}
+		return nil
+	},
+}
+
+// buildBrokerDescription combines the broker metadata and its configs
+func buildBrokerDescription(broker kadm.BrokerDetail, configs []kadm.Config, includeDefaults bool) brokerDescription {
+	keep := func(c kadm.Config) bool {
+		return includeDefaults || c.Source != kmsg.ConfigSourceDefaultConfig
+	}
+	desc := brokerDescription{
+		ID:      broker.NodeID,
+		Host:    broker.Host,
+		Port:    broker.Port,
+		Configs: buildConfigs(configs, keep),
+	}
+	if broker.Rack != nil {
+		desc.Rack = *broker.Rack
+	}
+	return desc
+}
+
+func printBrokerDescription(desc brokerDescription) {
+	color.Cyan("🖥️  Broker %d: %s:%d", desc.ID, desc.Host, desc.Port)
+	if desc.Rack != "" {
+		fmt.Printf("Rack: %s\n", desc.Rack)
+	}
+	fmt.Println()
+	if len(desc.Configs) == 0 {
+		color.Blue("Configs: all defaults")
+		return
+	}
+	color.Blue("Configs:")
+	printConfigs(desc.Configs)
+}
+
+// parseBrokerID parses a broker ID argument
+func parseBrokerID(arg string) (int32, error) {
+	id, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid broker ID %q", arg)
+	}
+	return int32(id), nil
+}
+
+func init() {
+	rootCmd.AddCommand(brokerCmd)
+	brokerCmd.AddCommand(describeBrokerCmd)
+	brokerCmd.AddCommand(alterBrokerConfigCmd)
+
+	describeBrokerCmd.Flags().StringVarP(&brokerDescribeOutput, "output", "o", OutputTable, "Output format: table or json")
+	describeBrokerCmd.Flags().BoolVar(&brokerDescribeAll, "all", false, "Include configs left at their default")
+
+	alterBrokerConfigCmd.Flags().StringArrayVar(&brokerAlterSet, "set", nil, "Config to set as key=value (repeatable)")
+	alterBrokerConfigCmd.Flags().StringArrayVar(&brokerAlterDelete, "delete", nil, "Config to delete (repeatable)")
+	alterBrokerConfigCmd.Flags().BoolVar(&brokerAlterDryRun, "dry-run", false, "Only validate the changes")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestBuildBrokerDescription(t *testing.T) {
+	rack := "rack-a"
+	threads, retention, password := "8", "168", ""
+	broker := kadm.BrokerDetail{NodeID: 1, Host: "kafka-1", Port: 9093, Rack: &rack}
+	configs := []kadm.Config{
+		{Key: "num.io.threads", Value: &threads, Source: kmsg.ConfigSourceDynamicBrokerConfig},
+		{Key: "log.retention.hours", Value: &retention, Source: kmsg.ConfigSourceDefaultConfig},
+		{Key: "ssl.keystore.password", Value: &password, Source: kmsg.ConfigSourceStaticBrokerConfig, Sensitive: true},
+	}
+
+	desc := buildBrokerDescription(broker, configs, false)
+	if desc.ID != 1 || desc.Rack != rack || desc.Port != 9093 {
+		t.Fatalf("unexpected broker %+v", desc)
+	}
+	if len(desc.Configs) != 2 || desc.Configs[0].Name != "num.io.threads" || desc.Configs[0].Source != "DYNAMIC_BROKER_CONFIG" {
+		t.Fatalf("expected the non-default configs sorted by name, got %+v", desc.Configs)
+	}
+	if !desc.Configs[1].Sensitive {
+		t.Fatalf("expected ssl.keystore.password to be sensitive, got %+v", desc.Configs[1])
+	}
+
+	if all := buildBrokerDescription(broker, configs, true); len(all.Configs) != 3 {
+		t.Fatalf("expected the defaults with --all, got %+v", all.Configs)
+	}
+}
+
+func TestParseBrokerID(t *testing.T) {
+	if id, err := parseBrokerID("42"); err != nil || id != 42 {
+		t.Fatalf("parseBrokerID(42) = %d, %v", id, err)
+	}
+	for _, arg := range []string{"", "-1", "one", "99999999999"} {
+		if _, err := parseBrokerID(arg); err == nil {
+			t.Fatalf("expected an error for %q", arg)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+var (
+	clusterInfoOutput  string
+	apiVersionsOutput  string
+	apiVersionsBrokers []int32
+)
+
+// clusterInfo is the info output of the cluster
+type clusterInfo struct {
+	ClusterID  string
+	Controller int32
+	Brokers    []brokerInfo
+}
+
+// brokerInfo is a broker of the cluster, as advertised in the metadata
+type brokerInfo struct {
+	ID         int32
+	Host       string
+	Port       int32
+	Rack       string `json:",omitempty"`
+	Controller bool
+}
+
+// brokerAPIVersions is the api-versions output of a broker
+type brokerAPIVersions struct {
+	Broker       int32
+	VersionGuess string       `json:",omitempty"`
+	APIs         []apiVersion `json:",omitempty"`
+	Error        string       `json:",omitempty"`
+}
+
+// apiVersion is the version range a broker supports for an API
+type apiVersion struct {
+	Key  int16
+	Name string
+	Min  int16
+	Max  int16
+}
+
+var clusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Inspect the Kafka cluster",
+}
+
+var clusterInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the cluster ID, controller and brokers",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clusterInfoOutput != OutputTable && clusterInfoOutput != OutputJSON {
+			return fmt.Errorf("unsupported --output %q (expected %s or %s)", clusterInfoOutput, OutputTable, OutputJSON)
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		meta, err := adminClient.BrokerMetadata(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to get cluster metadata: %w", err)
+		}
+
+		info := buildClusterInfo(meta)
+		if clusterInfoOutput == OutputJSON {
+			return writeJSON(info)
+		}
+		printClusterInfo(info)
+		return nil
+	},
+}
+
+var clusterAPIVersionsCmd = &cobra.Command{
+	Use:   "api-versions",
+	Short: "Show the API versions supported by each broker",
+	Long: `Show the minimum and maximum version of each Kafka API supported by each
+broker, with a guess of the Kafka version. Brokers that differ, e.g. during a
+rolling upgrade, are easy to spot in the table.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if apiVersionsOutput != OutputTable && apiVersionsOutput != OutputJSON {
+			return fmt.Errorf("unsupported --output %q (expected %s or %s)", apiVersionsOutput, OutputTable, OutputJSON)
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		versions, err := adminClient.ApiVersions(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to get API versions: %w", err)
+		}
+
+		brokers := buildAPIVersions(versions, apiVersionsBrokers)
+		if len(brokers) == 0 {
+			return fmt.Errorf("no broker matches --broker %s", joinInt32s(apiVersionsBrokers))
+		}
+		if apiVersionsOutput == OutputJSON {
+			return writeJSON(brokers)
+		}
+		printAPIVersions(brokers)
+		return nil
+	},
+}
+
+// buildClusterInfo sorts the brokers of meta by ID and marks the controller
+func buildClusterInfo(meta kadm.Metadata) clusterInfo {
+	info := clusterInfo{
+		ClusterID:  meta.Cluster,
+		Controller: meta.Controller,
+		Brokers:    []brokerInfo{},
+	}
+	for _, b := range meta.Brokers {
+		broker := brokerInfo{ID: b.NodeID, Host: b.Host, Port: b.Port, Controller: b.NodeID == meta.Controller}
+		if b.Rack != nil {
+			broker.Rack = *b.Rack
+		}
+		info.Brokers = append(info.Brokers, broker)
+	}
+	sort.Slice(info.Brokers, func(i, j int) bool { return info.Brokers[i].ID < info.Brokers[j].ID })
+	return info
+}
+
+func printClusterInfo(info clusterInfo) {
+	color.Cyan("🏢 Cluster: %s", info.ClusterID)
+	fmt.Printf("Controller: %s\n", leaderName(info.Controller))
+	fmt.Printf("Brokers: %d\n\n", len(info.Brokers))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tHOST\tPORT\tRACK\t")
+	for _, b := range info.Brokers {
+		rack := b.Rack
+		if rack == "" {
+			rack = "-"
+		}
+		controller := ""
+		if b.Controller {
+			controller = color.GreenString("controller")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\n", b.ID, b.Host, b.Port, rack, controller)
+	}
+	tw.Flush()
+}
+
+// buildAPIVersions converts the API versions of the brokers, sorted by ID,
+// keeping only the given brokers if any
+func buildAPIVersions(versions kadm.BrokersApiVersions, only []int32) []brokerAPIVersions {
+	brokers := []brokerAPIVersions{}
+	for id, v := range versions {
+		if len(only) > 0 && !slices.Contains(only, id) {
+			continue
+		}
+		broker := brokerAPIVersions{Broker: id}
+		if v.Err != nil {
+			broker.Error = v.Err.Error()
+		} else {
+			broker.VersionGuess = v.VersionGuess()
+			v.EachKeySorted(func(key, minVersion, maxVersion int16) {
+				broker.APIs = append(broker.APIs, apiVersion{Key: key, Name: kmsg.NameForKey(key), Min: minVersion, Max: maxVersion})
+			})
+		}
+		brokers = append(brokers, broker)
+	}
+	sort.Slice(brokers, func(i, j int) bool { return brokers[i].Broker < brokers[j].Broker })
+	return brokers
+}
+
+// printAPIVersions prints one row per API and one column per broker
+func printAPIVersions(brokers []brokerAPIVersions) {
+	type apiName struct {
+		key  int16
+		name string
+	}
+	ranges := make(map[int16]map[int32]string)
+	var apis []apiName
+	for _, b := range brokers {
+		if b.Error != "" {
+			color.Red("❌ Broker %d: %s", b.Broker, b.Error)
+			continue
+		}
+		color.Cyan("Broker %d: Kafka %s", b.Broker, b.VersionGuess)
+		for _, api := range b.APIs {
+			if ranges[api.Key] == nil {
+				ranges[api.Key] = make(map[int32]string)
+				apis = append(apis, apiName{api.Key, api.Name})
+			}
+			ranges[api.Key][b.Broker] = fmt.Sprintf("%d-%d", api.Min, api.Max)
+		}
+	}
+	if len(apis) == 0 {
+		return
+	}
+	sort.Slice(apis, func(i, j int) bool { return apis[i].key < apis[j].key })
+	fmt.Println()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	header := []string{"KEY", "API"}
+	for _, b := range brokers {
+		if b.Error == "" {
+			header = append(header, fmt.Sprintf("BROKER %d", b.Broker))
+		}
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+	for _, api := range apis {
+		row := []string{fmt.Sprint(api.key), api.name}
+		for _, b := range brokers {
+			if b.Error != "" {
+				continue
+			}
+			versions, ok := ranges[api.key][b.Broker]
+			if !ok {
+				versions = "-"
+			}
+			row = append(row, versions)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t")+"\t")
+	}
+	tw.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(clusterCmd)
+	clusterCmd.AddCommand(clusterInfoCmd)
+	clusterCmd.AddCommand(clusterAPIVersionsCmd)
+
+	clusterInfoCmd.Flags().StringVarP(&clusterInfoOutput, "output", "o", OutputTable, "Output format: table or json")
+	clusterAPIVersionsCmd.Flags().StringVarP(&apiVersionsOutput, "output", "o", OutputTable, "Output format: table or json")
+	clusterAPIVersionsCmd.Flags().Int32SliceVar(&apiVersionsBrokers, "broker", nil, "Only show these broker IDs (comma-separated or repeated)")
+}
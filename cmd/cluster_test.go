@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestBuildClusterInfo(t *testing.T) {
+	rack := "eu-west-1a"
+	meta := kadm.Metadata{
+		Cluster:    "lkc-123",
+		Controller: 2,
+		Brokers: kadm.BrokerDetails{
+			{NodeID: 3, Host: "kafka-3", Port: 9092},
+			{NodeID: 1, Host: "kafka-1", Port: 9092, Rack: &rack},
+			{NodeID: 2, Host: "kafka-2", Port: 9092},
+		},
+	}
+
+	info := buildClusterInfo(meta)
+
+	if info.ClusterID != "lkc-123" || info.Controller != 2 {
+		t.Fatalf("unexpected cluster %+v", info)
+	}
+	if len(info.Brokers) != 3 || info.Brokers[0].ID != 1 || info.Brokers[2].ID != 3 {
+		t.Fatalf("expected brokers sorted by ID, got %+v", info.Brokers)
+	}
+	if info.Brokers[0].Rack != rack || info.Brokers[1].Rack != "" {
+		t.Fatalf("unexpected racks %+v", info.Brokers)
+	}
+	if info.Brokers[0].Controller || !info.Brokers[1].Controller {
+		t.Fatalf("expected broker 2 to be the controller, got %+v", info.Brokers)
+	}
+}
+
+func TestBuildAPIVersionsFiltersBrokers(t *testing.T) {
+	errTest := errors.New("connection refused")
+	versions := kadm.BrokersApiVersions{
+		2: {NodeID: 2, Err: errTest},
+		1: {NodeID: 1, Err: errTest},
+		3: {NodeID: 3, Err: errTest},
+	}
+
+	all := buildAPIVersions(versions, nil)
+	if len(all) != 3 || all[0].Broker != 1 || all[2].Broker != 3 || all[0].Error != errTest.Error() {
+		t.Fatalf("unexpected brokers %+v", all)
+	}
+	if only := buildAPIVersions(versions, []int32{3}); len(only) != 1 || only[0].Broker != 3 {
+		t.Fatalf("expected only broker 3, got %+v", only)
+	}
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+// maskedSecret replaces passwords and tokens in config view
+const maskedSecret = "********"
+
+// OutputYAML is the yaml output format of config view
+const OutputYAML = "yaml"
+
+var (
+	listProfilesOutput string
+	viewProfileOutput  string
+)
+
+// profileSummary is the list output of a profile
+type profileSummary struct {
+	Name    string
+	Current bool
+	Brokers []string
+	Auth    string
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage connection profiles",
+	Long: `Manage the connection profiles ("contexts") of ~/.kafka-cli.yaml, or of the
+file given with --config:
+
+  current-context: local
+  defaults:
+    schema-registry:
+      url: http://localhost:8081
+  contexts:
+    local:
+      brokers: [localhost:9092]
+      tls: {enabled: false}
+    staging:
+      brokers: [kafka-1.staging:9093, kafka-2.staging:9093]
+      tls: {ca-file: /etc/ssl/staging-ca.pem}
+      sasl: {mechanism: SCRAM-SHA-512, username: app, password-file: ~/.staging-pass}
+    prod:
+      brokers: [b-1.prod.kafka.eu-west-1.amazonaws.com:9098]
+      aws: {iam: true, region: eu-west-1}
+      partitioner: murmur2
+
+Settings in defaults apply to every context. Any command uses the profile named
+with --profile (or --context, or $KAFKA_CLI_PROFILE), or the current context.
+Exported environment variables always override the profile; those of .env only
+override the current context.`,
+	// Profiles are managed here, a broken current context must not block them
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+var listProfilesCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateConfigOutput(); err != nil {
+			return err
+		}
+		file, err := loadProfilesFile()
+		if err != nil {
+			return err
+		}
+
+		summaries := make([]profileSummary, 0, len(file.Contexts))
+		for _, name := range file.Names() {
+			profile, err := file.Profile(name)
+			if err != nil {
+				return err
+			}
+			summaries = append(summaries, profileSummary{
+				Name:    name,
+				Current: strings.EqualFold(name, file.CurrentContext),
+				Brokers: profile.Brokers,
+				Auth:    profileAuth(profile),
+			})
+		}
+
+		if listProfilesOutput == OutputJSON {
+			return writeJSON(summaries)
+		}
+		if len(summaries) == 0 {
+			color.Yellow("No profiles in %s", file.Path)
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "CURRENT\tNAME\tBROKERS\tAUTH")
+		for _, s := range summaries {
+			current := ""
+			if s.Current {
+				current = "*"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", current, s.Name, strings.Join(s.Brokers, ","), s.Auth)
+		}
+		return tw.Flush()
+	},
+}
+
+var useContextCmd = &cobra.Command{
+	Use:   "use-context <name>",
+	Short: "Set the current context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, _, err := resolveProfilesFile()
+		if err != nil {
+			return err
+		}
+		if err := kafka.SetCurrentContext(path, args[0]); err != nil {
+			return err
+		}
+		color.Green("✅ Switched to context %q", strings.ToLower(args[0]))
+		return nil
+	},
+}
+
+var viewProfileCmd = &cobra.Command{
+	Use:   "view [name]",
+	Short: "Show a profile with its secrets masked",
+	Long: `Show a profile, merged with the defaults, with passwords and tokens masked.
+Without a name, show the profile selected with --profile or the current context.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if viewProfileOutput != OutputYAML && viewProfileOutput != OutputJSON {
+			return fmt.Errorf("unsupported --output %q (expected %s or %s)", viewProfileOutput, OutputYAML, OutputJSON)
+		}
+		file, err := loadProfilesFile()
+		if err != nil {
+			return err
+		}
+		name := selectedProfile()
+		if len(args) == 1 {
+			name = args[0]
+		}
+		if name == "" && file.CurrentContext == "" {
+			return fmt.Errorf("no current context in %s, name a profile", file.Path)
+		}
+		profile, err := file.Profile(name)
+		if err != nil {
+			return err
+		}
+
+		masked := maskProfile(*profile)
+		if viewProfileOutput == OutputJSON {
+			return writeJSON(masked)
+		}
+		color.Blue("Profile %s:", profile.Name)
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(masked); err != nil {
+			return err
+		}
+		return enc.Close()
+	},
+}
+
+// loadProfilesFile loads the profiles file, which must exist
+func loadProfilesFile() (*kafka.ProfilesFile, error) {
+	path, _, err := resolveProfilesFile()
+	if err != nil {
+		return nil, err
+	}
+	return kafka.LoadProfiles(path)
+}
+
+// profileAuth describes how a profile authenticates
+func profileAuth(p *kafka.Profile) string {
+	var auth []string
+	switch {
+	case p.AWS != nil && p.AWS.IAM != nil && *p.AWS.IAM:
+		auth = append(auth, "AWS_MSK_IAM")
+	case p.SASL != nil && p.SASL.Mechanism != "":
+		auth = append(auth, strings.ToUpper(p.SASL.Mechanism))
+	}
+	if p.TLS == nil || p.TLS.Enabled == nil || *p.TLS.Enabled {
+		auth = append(auth, "TLS")
+	}
+	if len(auth) == 0 {
+		return "none"
+	}
+	return strings.Join(auth, "+")
+}
+
+// maskProfile returns a copy of p with passwords and tokens masked
+func maskProfile(p kafka.Profile) kafka.Profile {
+	mask := func(s string) string {
+		// References such as exec:vault ... hold no secret
+		if s == "" || kafka.IsSecretReference(s) {
+			return s
+		}
+		return maskedSecret
+	}
+	if p.TLS != nil {
+		tls := *p.TLS
+		tls.KeyPassword = mask(tls.KeyPassword)
+		p.TLS = &tls
+	}
+	if p.SASL != nil {
+		sasl := *p.SASL
+		sasl.Password = mask(sasl.Password)
+		sasl.Token = mask(sasl.Token)
+		sasl.ClientSecret = mask(sasl.ClientSecret)
+		p.SASL = &sasl
+	}
+	if p.SchemaRegistry != nil {
+		registry := *p.SchemaRegistry
+		registry.Password = mask(registry.Password)
+		registry.BearerToken = mask(registry.BearerToken)
+		p.SchemaRegistry = &registry
+	}
+	return p
+}
+
+func validateConfigOutput() error {
+	if listProfilesOutput != OutputTable && listProfilesOutput != OutputJSON {
+		return fmt.Errorf("unsupported --output %q (expected %s or %s)", listProfilesOutput, OutputTable, OutputJSON)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(listProfilesCmd)
+	configCmd.AddCommand(useContextCmd)
+	configCmd.AddCommand(viewProfileCmd)
+
+	listProfilesCmd.Flags().StringVarP(&listProfilesOutput, "output", "o", OutputTable, "Output format: table or json")
+	viewProfileCmd.Flags().StringVarP(&viewProfileOutput, "output", "o", OutputYAML, "Output format: yaml or json")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+func TestMaskProfile(t *testing.T) {
+	profile := kafka.Profile{
+		SASL:           &kafka.ProfileSASL{Username: "app", Password: "secret", ClientSecret: "exec:vault kv get -field=secret kafka/app"},
+		SchemaRegistry: &kafka.ProfileSchemaRegistry{URL: "http://registry:8081", BearerToken: "token"},
+	}
+	masked := maskProfile(profile)
+
+	if masked.SASL.Password != maskedSecret || masked.SASL.Username != "app" || masked.SASL.ClientSecret != profile.SASL.ClientSecret {
+		t.Fatalf("unexpected SASL settings %+v", masked.SASL)
+	}
+	if masked.SchemaRegistry.BearerToken != maskedSecret || masked.SchemaRegistry.Password != "" {
+		t.Fatalf("unexpected registry settings %+v", masked.SchemaRegistry)
+	}
+	if profile.SASL.Password != "secret" {
+		t.Fatalf("maskProfile modified the profile")
+	}
+}
+
+func TestProfileAuth(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		profile kafka.Profile
+		want    string
+	}{
+		{kafka.Profile{}, "TLS"},
+		{kafka.Profile{TLS: &kafka.ProfileTLS{Enabled: &disabled}}, "none"},
+		{kafka.Profile{AWS: &kafka.ProfileAWS{IAM: &enabled}}, "AWS_MSK_IAM+TLS"},
+		{kafka.Profile{SASL: &kafka.ProfileSASL{Mechanism: "scram-sha-256"}, TLS: &kafka.ProfileTLS{Enabled: &disabled}}, "SCRAM-SHA-256"},
+	}
+	for _, tt := range tests {
+		if got := profileAuth(&tt.profile); got != tt.want {
+			t.Errorf("profileAuth(%+v) = %q, want %q", tt.profile, got, tt.want)
+		}
+	}
+}
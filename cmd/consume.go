@@ -5,6 +5,11 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/fatih/color"
@@ -14,95 +19,236 @@ import (
 	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
 )
 
+var (
+	consumeGroup         string
+	consumeFromBeginning bool
+	consumeOffset        int64
+	consumeFromTime      string
+	consumeMaxMessages   int
+	consumeTimeout       time.Duration
+	consumePartitions    []int32
+	consumeOutput        string
+	consumeTemplate      string
+	consumeFilter        string
+	consumeNoDecode      bool
+)
+
 // consumeCmd represents the consume command
 var consumeCmd = &cobra.Command{
-	Use:   "consume",
+	Use:   "consume <topic>",
 	Short: "Consume messages from a Kafka topic",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Consume messages from a Kafka topic until interrupted (Ctrl+C), --timeout
+elapses or --max-messages messages were read.
+
+Without --group, partitions are read directly and no offsets are committed.
+With --group, the consumer joins the group and commits what it read; the start
+position then only applies to partitions the group has not committed yet.
+
+Records are printed to stdout in the --output format; status messages go to
+stderr so the output can be piped to jq, grep or scripts.
+` + filterHelp + schemaHelp,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		topic := args[0]
 		if topic == "" {
-			color.Red("Topic is required")
+			return fmt.Errorf("topic is required")
+		}
+
+		offset, err := consumeStartOffset(cmd)
+		if err != nil {
+			return err
+		}
+		if consumeGroup != "" && len(consumePartitions) > 0 {
+			return fmt.Errorf("--partitions can't be used with --group, partitions are assigned by the group")
+		}
+		if consumeMaxMessages < 0 {
+			return fmt.Errorf("--max-messages must not be negative")
+		}
+
+		output := consumeOutput
+		if consumeTemplate != "" && !cmd.Flags().Changed("output") {
+			output = OutputTemplate
+		}
+		formatter, err := newRecordFormatter(os.Stdout, output, consumeTemplate)
+		if err != nil {
+			return err
+		}
+		filter, err := parseFilter(consumeFilter)
+		if err != nil {
+			return err
+		}
+
+		cfg := kafka.LoadConfig()
+		decoder, err := newRecordDecoder(cfg, consumeNoDecode, func(format string, args ...any) {
+			consumeStatus(color.FgYellow, format, args...)
+		})
+		if err != nil {
+			return err
 		}
-		color.Cyan("Consuming messages from topic: %s", topic)
 
-		readWithReader(topic, "consumer-through-kafka 1")
+		var client *kgo.Client
+		if consumeGroup != "" {
+			consumeStatus(color.FgCyan, "Consuming messages from topic: %s (group %s)", topic, consumeGroup)
+			client, err = cfg.CreateConsumer(consumeGroup, []string{topic}, kafka.WithConsumerOffset(offset))
+		} else {
+			consumeStatus(color.FgCyan, "Consuming messages from topic: %s", topic)
+			client, err = cfg.CreateDirectConsumer(topic, consumePartitions, offset)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create kafka client: %w", err)
+		}
+		defer client.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if consumeTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, consumeTimeout)
+			defer cancel()
+		}
+
+		consumed, scanned, consumeErr := consumeMessages(ctx, client, consumeMaxMessages, decoder, filter, formatter)
+
+		if consumeGroup != "" {
+			commitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := client.CommitUncommittedOffsets(commitCtx); err != nil {
+				consumeStatus(color.FgRed, "❌ Failed to commit offsets: %v", err)
+			}
+		}
+
+		switch {
+		case consumeErr != nil:
+			// Returned below
+		case consumeMaxMessages > 0 && consumed >= consumeMaxMessages:
+			consumeStatus(color.FgBlue, "Reached --max-messages")
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			consumeStatus(color.FgBlue, "Consumer timeout reached")
+		default:
+			consumeStatus(color.FgBlue, "Interrupted")
+		}
+		if filter != nil {
+			consumeStatus(color.FgCyan, "📊 Consumed %d messages matching the filter (%d read)", consumed, scanned)
+		} else {
+			consumeStatus(color.FgCyan, "📊 Consumed %d messages", consumed)
+		}
+		return consumeErr
 	},
 }
 
-// Read from the topic using franz-go client
-// Clients can use consumer groups for distributed consumption
-func readWithReader(topic, groupID string) {
-	cfg := kafka.LoadConfig()
+// consumeStartOffset returns where partitions without a committed offset start.
+// The default is the end of the partition, i.e. only new messages.
+func consumeStartOffset(cmd *cobra.Command) (kgo.Offset, error) {
+	set := 0
+	for _, name := range []string{"from-beginning", "offset", "from-time"} {
+		if cmd.Flags().Changed(name) {
+			set++
+		}
+	}
+	if set > 1 {
+		return kgo.Offset{}, fmt.Errorf("only one of --from-beginning, --offset or --from-time can be used")
+	}
 
-	// Create optimized consumer client
-	client, err := cfg.NewConsumerClient(groupID, topic)
-	if err != nil {
-		color.Red("failed to create kafka client: %v", err)
-		return
+	switch {
+	case consumeFromBeginning:
+		return kgo.NewOffset().AtStart(), nil
+	case cmd.Flags().Changed("offset"):
+		if consumeOffset < 0 {
+			return kgo.Offset{}, fmt.Errorf("--offset must not be negative")
+		}
+		return kgo.NewOffset().At(consumeOffset), nil
+	case consumeFromTime != "":
+		at, err := parseTimeWithTimezone(consumeFromTime)
+		if err != nil {
+			return kgo.Offset{}, fmt.Errorf("invalid --from-time: %w", err)
+		}
+		return kgo.NewOffset().AfterMilli(at.UnixMilli()), nil
+	default:
+		return kgo.NewOffset().AtEnd(), nil
 	}
-	defer client.Close()
-
-	// Create a deadline context
-	readDeadline, cancel := context.WithDeadline(context.Background(),
-		time.Now().Add(60*time.Second))
-	defer cancel()
-
-	// Poll for messages with shorter intervals for better responsiveness
-	for {
-		// Use a shorter context for each poll to make it more responsive
-		pollCtx, pollCancel := context.WithTimeout(readDeadline, 2*time.Second)
-		fetches := client.PollFetches(pollCtx)
-		pollCancel()
-
-		if errs := fetches.Errors(); len(errs) > 0 {
-			// Only log non-timeout errors to reduce noise
-			for _, err := range errs {
-				if err.Err.Error() != "context deadline exceeded" {
-					color.Red("fetch error: %v", err)
-				}
-			}
-			// Don't continue immediately on error, check if context is done
-			select {
-			case <-readDeadline.Done():
-				color.Blue("Consumer timeout reached")
-				return
-			default:
-				continue
-			}
+}
+
+// consumeMessages polls records, decodes them and writes the ones matching filter with
+// formatter until ctx is done or maxMessages (0 = unlimited) records were written. It
+// returns the number of records written and the number of records read.
+func consumeMessages(ctx context.Context, client *kgo.Client, maxMessages int, decoder *recordDecoder, filter *recordFilter, formatter recordFormatter) (int, int, error) {
+	consumed, scanned := 0, 0
+	for maxMessages == 0 || consumed < maxMessages {
+		// Never poll more than what is left, so a group only commits what was written
+		limit := 1000
+		if maxMessages > 0 && maxMessages-consumed < limit {
+			limit = maxMessages - consumed
 		}
 
-		if fetches.Empty() {
-			// Check if we've reached the deadline
-			select {
-			case <-readDeadline.Done():
-				color.Blue("Consumer timeout reached, no messages received")
-				return
-			default:
-				// Continue polling - no messages right now but keep trying
-				continue
-			}
+		fetches := client.PollRecords(ctx, limit)
+		if fetches.IsClientClosed() {
+			return consumed, scanned, nil
 		}
 
-		// Process all records
-		fetches.EachPartition(func(p kgo.FetchTopicPartition) {
-			for _, record := range p.Records {
-				color.Yellow("message at topic/partition/offset %v/%v/%v: %s = %s",
-					record.Topic, record.Partition, record.Offset, string(record.Key), string(record.Value))
-			}
-		})
+		// Records polled when ctx is done are written too: a group commits their offsets
+		written, read, err := writeRecords(ctx, fetches, decoder, filter, formatter)
+		consumed += written
+		scanned += read
+		if err != nil || ctx.Err() != nil {
+			return consumed, scanned, err
+		}
 	}
+	return consumed, scanned, nil
 }
 
-func init() {
-	rootCmd.AddCommand(consumeCmd)
+// writeRecords decodes the polled records and writes the ones matching filter with
+// formatter. It returns the number of records written and the number of records read.
+func writeRecords(ctx context.Context, fetches kgo.Fetches, decoder *recordDecoder, filter *recordFilter, formatter recordFormatter) (int, int, error) {
+	fetches.EachError(func(topic string, partition int32, err error) {
+		if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			consumeStatus(color.FgRed, "fetch error on %s/%d: %v", topic, partition, err)
+		}
+	})
+
+	// Still decode the records polled as the consumer was interrupted
+	decodeCtx := ctx
+	if ctx.Err() != nil {
+		decodeCtx = context.WithoutCancel(ctx)
+	}
+
+	consumed, scanned := 0, 0
+	var formatErr error
+	fetches.EachRecord(func(record *kgo.Record) {
+		if formatErr != nil {
+			return
+		}
+		scanned++
+		decoder.Decode(decodeCtx, record)
+		if !filter.Match(record) {
+			return
+		}
+		if formatErr = formatter.Format(record); formatErr == nil {
+			consumed++
+		}
+	})
+	if err := formatter.Flush(); err != nil && formatErr == nil {
+		formatErr = fmt.Errorf("failed to write output: %w", err)
+	}
+	return consumed, scanned, formatErr
+}
 
-	// Here you will define your flags and configuration settings.
+// consumeStatus prints a colored status line on stderr, keeping stdout for records
+func consumeStatus(attr color.Attribute, format string, args ...any) {
+	color.New(attr).Fprintf(os.Stderr, format+"\n", args...)
+}
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// consumeCmd.PersistentFlags().String("foo", "", "A help for foo")
+func init() {
+	rootCmd.AddCommand(consumeCmd)
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// consumeCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	consumeCmd.Flags().StringVarP(&consumeGroup, "group", "g", "", "Consumer group to join and commit offsets to (default: no group, nothing committed)")
+	consumeCmd.Flags().BoolVar(&consumeFromBeginning, "from-beginning", false, "Start from the earliest available message")
+	consumeCmd.Flags().Int64Var(&consumeOffset, "offset", 0, "Start from this offset")
+	consumeCmd.Flags().StringVar(&consumeFromTime, "from-time", "", "Start from the first message at or after this time (same formats as extract)")
+	consumeCmd.Flags().IntVarP(&consumeMaxMessages, "max-messages", "n", 0, "Stop after this many messages (0 = unlimited)")
+	consumeCmd.Flags().DurationVar(&consumeTimeout, "timeout", 0, "Stop after this duration, e.g. 30s or 5m (0 = until interrupted)")
+	consumeCmd.Flags().Int32SliceVarP(&consumePartitions, "partitions", "p", nil, "Only read these partitions, e.g. 0,2 (not with --group)")
+	consumeCmd.Flags().StringVarP(&consumeOutput, "output", "o", OutputPretty, "Output format: pretty, json, ndjson, raw (value only), table or template")
+	consumeCmd.Flags().StringVar(&consumeFilter, "filter", "", `Only print messages matching this expression, e.g. '.user.id == 42 and headers.source == "web"'`)
+	consumeCmd.Flags().BoolVar(&consumeNoDecode, "no-decode", false, "Don't decode Schema Registry encoded keys and values")
+	consumeCmd.Flags().StringVar(&consumeTemplate, "template", "", `Go template for -o template, e.g. '{{.Partition}} {{.Key}} {{.Value}}'`)
 }
@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Output formats of consume, in addition to OutputJSON and OutputTable.
+// OutputJSON and OutputNDJSON write the same envelopes as extract, so the
+// output can be replayed with produce -i.
+const (
+	OutputPretty   = "pretty"
+	OutputNDJSON   = "ndjson"
+	OutputRaw      = "raw"
+	OutputTemplate = "template"
+)
+
+// consumeOutputs lists the formats accepted by consume -o
+var consumeOutputs = []string{OutputPretty, OutputJSON, OutputNDJSON, OutputRaw, OutputTable, OutputTemplate}
+
+// recordFormatter writes consumed records to an output. Flush is called after
+// every poll so records show up while tailing a topic.
+type recordFormatter interface {
+	Format(record *kgo.Record) error
+	Flush() error
+}
+
+// newRecordFormatter returns the formatter for format. tmpl is the Go template
+// used by OutputTemplate and ignored otherwise.
+//
+// Colors are only used by the pretty format and follow color.NoColor, which is
+// set when stdout is not a terminal or NO_COLOR is set.
+func newRecordFormatter(w io.Writer, format, tmpl string) (recordFormatter, error) {
+	bw := bufio.NewWriterSize(w, 64*1024)
+	switch format {
+	case OutputPretty:
+		return &prettyFormatter{w: bw}, nil
+	case OutputJSON:
+		return &envelopeFormatter{w: bw, indent: true}, nil
+	case OutputNDJSON:
+		return &envelopeFormatter{w: bw}, nil
+	case OutputRaw:
+		return &rawFormatter{w: bw}, nil
+	case OutputTable:
+		return &tableFormatter{w: bw}, nil
+	case OutputTemplate:
+		if tmpl == "" {
+			return nil, fmt.Errorf("--template is required with -o %s", OutputTemplate)
+		}
+		t, err := template.New("record").Funcs(templateFuncs).Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --template: %w", err)
+		}
+		return &templateFormatter{w: bw, tmpl: t, newline: !strings.HasSuffix(tmpl, "\n")}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output %q (expected one of %s)", format, strings.Join(consumeOutputs, ", "))
+	}
+}
+
+// prettyFormatter prints a colored, human friendly block per record
+type prettyFormatter struct {
+	w *bufio.Writer
+}
+
+var (
+	prettyMeta   = color.New(color.FgCyan).SprintFunc()
+	prettyKey    = color.New(color.FgYellow).SprintFunc()
+	prettyHeader = color.New(color.FgBlue).SprintFunc()
+	prettyNote   = color.New(color.Faint).SprintFunc()
+)
+
+// Format prints the record position, key, headers and value. JSON values are indented.
+func (f *prettyFormatter) Format(r *kgo.Record) error {
+	note := r.Timestamp.Format(time.RFC3339Nano)
+	if ids := schemaIDsOf(r); ids.Key > 0 || ids.Value > 0 {
+		note += fmt.Sprintf(" (schema key %s, value %s)", schemaIDName(ids.Key), schemaIDName(ids.Value))
+	}
+	fmt.Fprintf(f.w, "%s %s\n",
+		prettyMeta(fmt.Sprintf("%s/%d@%d", r.Topic, r.Partition, r.Offset)),
+		prettyNote(note))
+
+	if r.Key != nil {
+		fmt.Fprintf(f.w, "%s %s\n", prettyKey("key:"), printableBytes(r.Key))
+	}
+	for _, h := range r.Headers {
+		fmt.Fprintf(f.w, "%s %s=%s\n", prettyHeader("header:"), h.Key, printableBytes(h.Value))
+	}
+
+	switch {
+	case r.Value == nil:
+		fmt.Fprintln(f.w, prettyNote("(tombstone)"))
+	case isJSONDocument(r.Value):
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, bytes.TrimSpace(r.Value), "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		buf.WriteTo(f.w)
+	default:
+		fmt.Fprintln(f.w, printableBytes(r.Value))
+	}
+	_, err := f.w.WriteString("\n")
+	return err
+}
+
+// Flush writes buffered output
+func (f *prettyFormatter) Flush() error { return f.w.Flush() }
+
+// envelopeFormatter writes each record as a MessageEnvelope, one compact JSON
+// object per line or one indented object per record
+type envelopeFormatter struct {
+	w      *bufio.Writer
+	indent bool
+}
+
+// Format writes the record envelope
+func (f *envelopeFormatter) Format(r *kgo.Record) error {
+	env := newMessageEnvelope(r, EncodingAuto)
+	var (
+		data []byte
+		err  error
+	)
+	if f.indent {
+		data, err = json.MarshalIndent(env, "", "  ")
+	} else {
+		data, err = json.Marshal(env)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	f.w.Write(data)
+	return f.w.WriteByte('\n')
+}
+
+// Flush writes buffered output
+func (f *envelopeFormatter) Flush() error { return f.w.Flush() }
+
+// rawFormatter writes the value bytes of each record followed by a newline
+type rawFormatter struct {
+	w *bufio.Writer
+}
+
+// Format writes the record value as is
+func (f *rawFormatter) Format(r *kgo.Record) error {
+	f.w.Write(r.Value)
+	return f.w.WriteByte('\n')
+}
+
+// Flush writes buffered output
+func (f *rawFormatter) Flush() error { return f.w.Flush() }
+
+// tableFormatter writes one fixed-width row per record, with a header before the first one
+type tableFormatter struct {
+	w      *bufio.Writer
+	header bool
+}
+
+const tableKeyWidth = 24
+
+// Format writes the record as a table row
+func (f *tableFormatter) Format(r *kgo.Record) error {
+	if !f.header {
+		fmt.Fprintf(f.w, "%-9s  %-12s  %-24s  %-*s  %s\n", "PARTITION", "OFFSET", "TIMESTAMP", tableKeyWidth, "KEY", "VALUE")
+		f.header = true
+	}
+
+	key := "-"
+	if r.Key != nil {
+		key = truncate(singleLine(printableBytes(r.Key)), tableKeyWidth)
+	}
+	value := "(tombstone)"
+	if r.Value != nil {
+		value = singleLine(printableBytes(r.Value))
+	}
+	_, err := fmt.Fprintf(f.w, "%-9d  %-12d  %-24s  %-*s  %s\n",
+		r.Partition, r.Offset, r.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z"), tableKeyWidth, key, value)
+	return err
+}
+
+// Flush writes buffered output
+func (f *tableFormatter) Flush() error { return f.w.Flush() }
+
+// templateFormatter renders each record with a Go text/template
+type templateFormatter struct {
+	w       *bufio.Writer
+	tmpl    *template.Template
+	newline bool
+}
+
+// templateRecord is the data available to --template.
+// Key and Value are the raw bytes as strings, or JSON when decoded with a schema
+// (KeySchemaID and ValueSchemaID are then set); Headers keeps the last value of each key.
+type templateRecord struct {
+	Topic         string
+	Partition     int32
+	Offset        int64
+	Timestamp     time.Time
+	LeaderEpoch   int32
+	Key           string
+	Value         string
+	KeySchemaID   int
+	ValueSchemaID int
+	Headers       map[string]string
+	HeaderList    []EnvelopeHeader
+}
+
+// templateFuncs are the helpers available in --template
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		if s, ok := v.(string); ok && json.Valid([]byte(s)) {
+			return s, nil
+		}
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"base64": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"hex":    func(s string) string { return hex.EncodeToString([]byte(s)) },
+}
+
+// Format renders the template for the record, adding a newline unless the template ends with one
+func (f *templateFormatter) Format(r *kgo.Record) error {
+	ids := schemaIDsOf(r)
+	data := templateRecord{
+		Topic:         r.Topic,
+		Partition:     r.Partition,
+		Offset:        r.Offset,
+		Timestamp:     r.Timestamp,
+		LeaderEpoch:   r.LeaderEpoch,
+		Key:           string(r.Key),
+		Value:         string(r.Value),
+		KeySchemaID:   ids.Key,
+		ValueSchemaID: ids.Value,
+		Headers:       make(map[string]string, len(r.Headers)),
+		HeaderList:    make([]EnvelopeHeader, 0, len(r.Headers)),
+	}
+	for _, h := range r.Headers {
+		data.Headers[h.Key] = string(h.Value)
+		data.HeaderList = append(data.HeaderList, EnvelopeHeader{Key: h.Key, Value: string(h.Value)})
+	}
+
+	if err := f.tmpl.Execute(f.w, data); err != nil {
+		return fmt.Errorf("failed to render --template: %w", err)
+	}
+	if f.newline {
+		return f.w.WriteByte('\n')
+	}
+	return nil
+}
+
+// Flush writes buffered output
+func (f *templateFormatter) Flush() error { return f.w.Flush() }
+
+// printableBytes returns b as text, or base64 prefixed with "base64:" when it is not valid UTF-8
+func printableBytes(b []byte) string {
+	s, encoding := encodeBytes(b, EncodingAuto)
+	if encoding == EncodingBase64 {
+		return "base64:" + s
+	}
+	return s
+}
+
+// schemaIDName returns the schema ID, or "-" for data that was not decoded
+func schemaIDName(id int) string {
+	if id <= 0 {
+		return "-"
+	}
+	return fmt.Sprint(id)
+}
+
+// singleLine replaces line breaks so a value fits on one table row
+func singleLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
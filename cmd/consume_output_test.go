@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func consumedRecord() *kgo.Record {
+	return &kgo.Record{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Timestamp: time.Date(2025, 10, 8, 13, 0, 0, 0, time.UTC),
+		Key:       []byte("order-1"),
+		Value:     []byte(`{"id":1}`),
+		Headers:   []kgo.RecordHeader{{Key: "trace", Value: []byte("abc")}},
+	}
+}
+
+func formatRecords(t *testing.T, format, tmpl string, records ...*kgo.Record) string {
+	t.Helper()
+	var buf bytes.Buffer
+	f, err := newRecordFormatter(&buf, format, tmpl)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, r := range records {
+		if err := f.Format(r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestRecordFormatterNDJSON(t *testing.T) {
+	out := formatRecords(t, OutputNDJSON, "", consumedRecord(), consumedRecord())
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), out)
+	}
+
+	var env MessageEnvelope
+	if err := json.Unmarshal([]byte(lines[0]), &env); err != nil {
+		t.Fatalf("expected a valid envelope, got %v", err)
+	}
+	if env.Topic != "orders" || *env.Offset != 42 || string(env.Message) != `{"id":1}` || *env.Key != "order-1" {
+		t.Fatalf("unexpected envelope: %+v", env)
+	}
+}
+
+func TestRecordFormatterRaw(t *testing.T) {
+	tombstone := consumedRecord()
+	tombstone.Value = nil
+	if out := formatRecords(t, OutputRaw, "", consumedRecord(), tombstone); out != "{\"id\":1}\n\n" {
+		t.Fatalf("unexpected raw output: %q", out)
+	}
+}
+
+func TestRecordFormatterTemplate(t *testing.T) {
+	out := formatRecords(t, OutputTemplate, `{{.Partition}}/{{.Offset}} {{.Key}} {{index .Headers "trace"}} {{.Value}}`, consumedRecord())
+	if out != "2/42 order-1 abc {\"id\":1}\n" {
+		t.Fatalf("unexpected template output: %q", out)
+	}
+
+	if _, err := newRecordFormatter(&bytes.Buffer{}, OutputTemplate, ""); err == nil {
+		t.Fatalf("expected error without --template")
+	}
+	if _, err := newRecordFormatter(&bytes.Buffer{}, OutputTemplate, "{{.Value"); err == nil {
+		t.Fatalf("expected error for an invalid template")
+	}
+}
+
+func TestRecordFormatterTable(t *testing.T) {
+	out := formatRecords(t, OutputTable, "", consumedRecord())
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "PARTITION") {
+		t.Fatalf("expected a header and one row, got %q", out)
+	}
+	if !strings.Contains(lines[1], "2025-10-08T13:00:00.000Z") || !strings.HasSuffix(lines[1], `{"id":1}`) {
+		t.Fatalf("unexpected row: %q", lines[1])
+	}
+}
+
+func TestRecordFormatterPrettyWithoutColor(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	out := formatRecords(t, OutputPretty, "", consumedRecord())
+	if strings.Contains(out, "\x1b[") {
+		t.Fatalf("expected no escape codes, got %q", out)
+	}
+	for _, want := range []string{"orders/2@42", "key: order-1", "header: trace=abc", "\"id\": 1"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in %q", want, out)
+		}
+	}
+}
+
+func TestRecordFormatterUnknown(t *testing.T) {
+	if _, err := newRecordFormatter(&bytes.Buffer{}, "xml", ""); err == nil {
+		t.Fatalf("expected error for an unknown format")
+	}
+}
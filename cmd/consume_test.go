@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func newStartOffsetCmd(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	consumeFromBeginning, consumeOffset, consumeFromTime = false, 0, ""
+
+	cmd := &cobra.Command{}
+	cmd.Flags().BoolVar(&consumeFromBeginning, "from-beginning", false, "")
+	cmd.Flags().Int64Var(&consumeOffset, "offset", 0, "")
+	cmd.Flags().StringVar(&consumeFromTime, "from-time", "", "")
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	return cmd
+}
+
+func TestConsumeStartOffset(t *testing.T) {
+	at := time.Date(2025, 10, 8, 13, 0, 0, 0, time.UTC)
+	tests := []struct {
+		args []string
+		want kgo.Offset
+	}{
+		{nil, kgo.NewOffset().AtEnd()},
+		{[]string{"--from-beginning"}, kgo.NewOffset().AtStart()},
+		{[]string{"--offset", "0"}, kgo.NewOffset().At(0)},
+		{[]string{"--offset", "42"}, kgo.NewOffset().At(42)},
+		{[]string{"--from-time", "2025-10-08T13:00:00Z"}, kgo.NewOffset().AfterMilli(at.UnixMilli())},
+	}
+	for _, tt := range tests {
+		got, err := consumeStartOffset(newStartOffsetCmd(t, tt.args...))
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args, err)
+		}
+		if got.String() != tt.want.String() {
+			t.Fatalf("%v: expected %s, got %s", tt.args, tt.want, got)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--from-beginning", "--offset", "3"},
+		{"--offset", "-1"},
+		{"--from-time", "yesterday"},
+	} {
+		if _, err := consumeStartOffset(newStartOffsetCmd(t, args...)); err == nil {
+			t.Fatalf("%v: expected error", args)
+		}
+	}
+}
+
+func TestWriteRecordsAfterCancel(t *testing.T) {
+	var out bytes.Buffer
+	formatter, err := newRecordFormatter(&out, OutputRaw, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fetches := kgo.Fetches{{Topics: []kgo.FetchTopic{{Topic: "events", Partitions: []kgo.FetchPartition{{
+		Partition: 0,
+		Err:       context.Canceled,
+		Records: []*kgo.Record{
+			{Topic: "events", Offset: 7, Value: []byte("a")},
+			{Topic: "events", Offset: 8, Value: []byte("b")},
+		},
+	}}}}}}
+	consumed, scanned, err := writeRecords(ctx, fetches, nil, nil, formatter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consumed != 2 || scanned != 2 || out.String() != "a\nb\n" {
+		t.Fatalf("consumed %d, scanned %d, output %q", consumed, scanned, out.String())
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+var (
+	doctorTimeout time.Duration
+	doctorOutput  string
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the connection to the Kafka cluster",
+	Long: `Check the connection to each bootstrap broker step by step: DNS resolution,
+TCP connection, TLS handshake and certificate chain, SASL authentication and
+ApiVersions, then fetch the cluster metadata. Failed steps come with a hint,
+e.g. a port that does not match MSK IAM, an expired certificate or a
+certificate issued for another host name.
+
+Exits with an error when a step fails.`,
+	Example: `  kafka-cli doctor
+  kafka-cli --profile prod-eu doctor --timeout 5s
+  kafka-cli doctor -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if doctorOutput != OutputTable && doctorOutput != OutputJSON {
+			return fmt.Errorf("unsupported --output %q (expected %s or %s)", doctorOutput, OutputTable, OutputJSON)
+		}
+		if doctorTimeout <= 0 {
+			return fmt.Errorf("--timeout must be positive")
+		}
+		// Failures are reported by the checks, usage would only hide them
+		cmd.SilenceUsage = true
+
+		checks := []kafka.Check{}
+		report := func(check kafka.Check) {
+			if doctorOutput == OutputTable {
+				printCheck(check, checks)
+			}
+			checks = append(checks, check)
+		}
+
+		if doctorOutput == OutputTable {
+			color.Cyan("🩺 Diagnosing the Kafka connection")
+		}
+		cfg, err := kafka.NewConfig()
+		if err != nil {
+			report(kafka.Check{Step: kafka.StepConfig, Status: kafka.CheckFail, Detail: err.Error()})
+		} else {
+			cfg.Diagnose(context.Background(), doctorTimeout, report)
+		}
+
+		if doctorOutput == OutputJSON {
+			if err := writeJSON(checks); err != nil {
+				return err
+			}
+		}
+		failed, warned := countChecks(checks)
+		if failed > 0 {
+			return fmt.Errorf("%d check(s) failed", failed)
+		}
+		if doctorOutput == OutputTable {
+			fmt.Println()
+			if warned > 0 {
+				color.Yellow("⚠️  Connected, with %d warning(s)", warned)
+			} else {
+				color.Green("✅ All checks passed")
+			}
+		}
+		return nil
+	},
+}
+
+// printCheck prints a check, under a header when it is the first of its broker
+func printCheck(check kafka.Check, previous []kafka.Check) {
+	indent := ""
+	if check.Broker != "" {
+		indent = "  "
+		if len(previous) == 0 || previous[len(previous)-1].Broker != check.Broker {
+			fmt.Println()
+			color.Blue("🔌 %s", check.Broker)
+		}
+	} else if len(previous) > 0 && previous[len(previous)-1].Broker != "" {
+		fmt.Println()
+	}
+
+	fmt.Printf("%s%s %-13s %s\n", indent, checkIcon(check.Status), check.Step, check.Detail)
+	for _, line := range check.Details {
+		fmt.Printf("%s   %-13s %s\n", indent, "", line)
+	}
+	if check.Hint != "" {
+		color.Yellow("%s   💡 %s", indent, check.Hint)
+	}
+}
+
+func checkIcon(status kafka.CheckStatus) string {
+	switch status {
+	case kafka.CheckOK:
+		return "✅"
+	case kafka.CheckWarn:
+		return "⚠️ "
+	case kafka.CheckFail:
+		return "❌"
+	default:
+		return "⏭️ "
+	}
+}
+
+// countChecks counts the failed and warning checks
+func countChecks(checks []kafka.Check) (failed, warned int) {
+	for _, check := range checks {
+		switch check.Status {
+		case kafka.CheckFail:
+			failed++
+		case kafka.CheckWarn:
+			warned++
+		}
+	}
+	return failed, warned
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 10*time.Second, "Timeout of each network step")
+	doctorCmd.Flags().StringVarP(&doctorOutput, "output", "o", OutputTable, "Output format: table or json")
+}
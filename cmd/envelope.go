@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+// Supported encodings for keys, values and header values in a MessageEnvelope
+const (
+	EncodingNone   = ""
+	EncodingUTF8   = "utf8"
+	EncodingBase64 = "base64"
+	EncodingHex    = "hex"
+	// EncodingAuto is only used when extracting: JSON objects and arrays are kept as Message,
+	// valid UTF-8 is written as text and anything else is base64 encoded
+	EncodingAuto = "auto"
+)
+
+// Timestamp types as reported by Kafka
+const (
+	TimestampTypeCreate     = "CreateTime"
+	TimestampTypeLogAppend  = "LogAppendTime"
+	TimestampTypeNotPresent = "NoTimestamp"
+)
+
+// MessageEnvelope is the file representation of a Kafka record, used both by
+// extract (output) and produce -i (input).
+//
+// Only Topic and at most one value source are needed to produce. The value is
+// taken from Message (any JSON value, sent compacted), Value (text, or encoded
+// with ValueEncoding), ValueString (text), ValueBase64 (binary) or ValueFile
+// (raw bytes of a file). Without any of them, a tombstone (null value) is sent.
+//
+// The remaining fields carry the record metadata so an extracted file can be
+// replayed exactly: Key, Partition and Timestamp are honored when producing,
+// while Offset, TimestampType and LeaderEpoch are informational.
+//
+// KeySchemaID and ValueSchemaID are set when the key or value was decoded from
+// the Schema Registry wire format; produce encodes them again with that schema.
+// KeyMessageType and ValueMessageType name the Protobuf message they were
+// decoded as, so they are encoded again as the same message.
+type MessageEnvelope struct {
+	Topic            string          `json:"Topic"`
+	Partition        *int32          `json:"Partition,omitempty"`
+	Offset           *int64          `json:"Offset,omitempty"`
+	Timestamp        *time.Time      `json:"Timestamp,omitempty"`
+	TimestampType    string          `json:"TimestampType,omitempty"`
+	LeaderEpoch      *int32          `json:"LeaderEpoch,omitempty"`
+	Key              *string         `json:"Key,omitempty"`
+	KeyEncoding      string          `json:"KeyEncoding,omitempty"`
+	KeySchemaID      *int            `json:"KeySchemaID,omitempty"`
+	KeyMessageType   string          `json:"KeyMessageType,omitempty"`
+	Headers          EnvelopeHeaders `json:"Headers,omitempty"`
+	HeaderEncoding   string          `json:"HeaderEncoding,omitempty"`
+	Message          json.RawMessage `json:"Message,omitempty"`
+	Value            *string         `json:"Value,omitempty"`
+	ValueEncoding    string          `json:"ValueEncoding,omitempty"`
+	ValueSchemaID    *int            `json:"ValueSchemaID,omitempty"`
+	ValueMessageType string          `json:"ValueMessageType,omitempty"`
+	ValueString      *string         `json:"ValueString,omitempty"`
+	ValueBase64      *string         `json:"ValueBase64,omitempty"`
+	ValueFile        string          `json:"ValueFile,omitempty"`
+}
+
+// EnvelopeHeader is a single record header. Its value follows the envelope HeaderEncoding.
+type EnvelopeHeader struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// EnvelopeHeaders keeps record headers in order, including duplicate keys.
+//
+// It is written as a JSON object ({"k": "v"}) when keys are unique, and as an
+// array of {"Key": ..., "Value": ...} objects otherwise. Both forms are accepted
+// when reading.
+type EnvelopeHeaders []EnvelopeHeader
+
+// MarshalJSON writes the headers as an object, falling back to an array on duplicate keys
+func (h EnvelopeHeaders) MarshalJSON() ([]byte, error) {
+	if h == nil {
+		return []byte("null"), nil
+	}
+
+	seen := make(map[string]bool, len(h))
+	for _, header := range h {
+		if seen[header.Key] {
+			return json.Marshal([]EnvelopeHeader(h))
+		}
+		seen[header.Key] = true
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, header := range h {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(header.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(header.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON reads headers from either the object or the array form, preserving order
+func (h *EnvelopeHeaders) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*h = nil
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '[' {
+		var list []EnvelopeHeader
+		if err := json.Unmarshal(data, &list); err != nil {
+			return fmt.Errorf("invalid headers: %w", err)
+		}
+		*h = list
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("invalid headers: expected an object or an array")
+	}
+
+	headers := EnvelopeHeaders{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("invalid headers: %w", err)
+		}
+		key, _ := tok.(string)
+
+		var value string
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("invalid value for header %q: %w", key, err)
+		}
+		headers = append(headers, EnvelopeHeader{Key: key, Value: value})
+	}
+	*h = headers
+	return nil
+}
+
+// newMessageEnvelope converts a consumed record into the envelope written to the output file.
+// encoding is one of EncodingAuto, EncodingBase64 or EncodingHex.
+func newMessageEnvelope(record *kgo.Record, encoding string) MessageEnvelope {
+	partition := record.Partition
+	offset := record.Offset
+	timestamp := record.Timestamp
+	leaderEpoch := record.LeaderEpoch
+
+	env := MessageEnvelope{
+		Topic:         record.Topic,
+		Partition:     &partition,
+		Offset:        &offset,
+		Timestamp:     &timestamp,
+		TimestampType: timestampTypeName(record.Attrs.TimestampType()),
+		LeaderEpoch:   &leaderEpoch,
+	}
+
+	if record.Key != nil {
+		key, keyEncoding := encodeBytes(record.Key, encoding)
+		env.Key, env.KeyEncoding = &key, keyEncoding
+	}
+
+	ids := schemaIDsOf(record)
+	if ids.Key > 0 {
+		env.KeySchemaID, env.KeyMessageType = &ids.Key, ids.KeyMessageType
+	}
+	if ids.Value > 0 {
+		env.ValueSchemaID, env.ValueMessageType = &ids.Value, ids.ValueMessageType
+	}
+
+	if len(record.Headers) > 0 {
+		headerEncoding := encoding
+		if encoding == EncodingAuto {
+			// Header values share one encoding; only fall back to base64 when needed
+			headerEncoding = EncodingUTF8
+			for _, h := range record.Headers {
+				if !utf8.Valid(h.Value) {
+					headerEncoding = EncodingBase64
+					break
+				}
+			}
+		}
+		for _, h := range record.Headers {
+			value, _ := encodeBytes(h.Value, headerEncoding)
+			env.Headers = append(env.Headers, EnvelopeHeader{Key: h.Key, Value: value})
+		}
+		if headerEncoding != EncodingUTF8 {
+			env.HeaderEncoding = headerEncoding
+		}
+	}
+
+	if record.Value == nil {
+		// Tombstone: neither Message nor Value is set
+		return env
+	}
+
+	if encoding == EncodingAuto && isJSONDocument(record.Value) {
+		env.Message = json.RawMessage(record.Value)
+		return env
+	}
+
+	value, valueEncoding := encodeBytes(record.Value, encoding)
+	env.Value, env.ValueEncoding = &value, valueEncoding
+	return env
+}
+
+// Record converts the envelope into a record ready to be produced.
+// Records without an explicit Partition are left to the producer partitioner.
+func (e MessageEnvelope) Record() (*kgo.Record, error) {
+	if e.Topic == "" {
+		return nil, fmt.Errorf("missing Topic")
+	}
+
+	record := &kgo.Record{
+		Topic:     e.Topic,
+		Partition: kafka.UnassignedPartition,
+	}
+
+	if e.Partition != nil {
+		if *e.Partition < 0 {
+			return nil, fmt.Errorf("invalid Partition %d", *e.Partition)
+		}
+		record.Partition = *e.Partition
+	}
+
+	if e.Timestamp != nil {
+		record.Timestamp = *e.Timestamp
+	}
+
+	if e.Key != nil {
+		key, err := decodeBytes(*e.Key, e.KeyEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Key: %w", err)
+		}
+		record.Key = key
+	}
+
+	for _, h := range e.Headers {
+		value, err := decodeBytes(h.Value, e.HeaderEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for header %q: %w", h.Key, err)
+		}
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: h.Key, Value: value})
+	}
+
+	value, err := e.recordValue()
+	if err != nil {
+		return nil, err
+	}
+	record.Value = value
+
+	return record, nil
+}
+
+// recordValue resolves the record value from the single value source set in the envelope
+func (e MessageEnvelope) recordValue() ([]byte, error) {
+	hasMessage := len(e.Message) > 0 && !bytes.Equal(bytes.TrimSpace(e.Message), []byte("null"))
+
+	sources := 0
+	for _, set := range []bool{hasMessage, e.Value != nil, e.ValueString != nil, e.ValueBase64 != nil, e.ValueFile != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, fmt.Errorf("only one of Message, Value, ValueString, ValueBase64 or ValueFile may be set")
+	}
+
+	switch {
+	case hasMessage:
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, e.Message); err != nil {
+			return nil, fmt.Errorf("invalid Message: %w", err)
+		}
+		return buf.Bytes(), nil
+	case e.Value != nil:
+		value, err := decodeBytes(*e.Value, e.ValueEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Value: %w", err)
+		}
+		return value, nil
+	case e.ValueString != nil:
+		return []byte(*e.ValueString), nil
+	case e.ValueBase64 != nil:
+		value, err := base64.StdEncoding.DecodeString(*e.ValueBase64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ValueBase64: %w", err)
+		}
+		return value, nil
+	case e.ValueFile != "":
+		value, err := os.ReadFile(e.ValueFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ValueFile: %w", err)
+		}
+		return value, nil
+	}
+	return nil, nil
+}
+
+// isJSONDocument reports whether b is a valid JSON object or array
+func isJSONDocument(b []byte) bool {
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return false
+	}
+	return json.Valid(trimmed)
+}
+
+// encodeBytes encodes b with the requested encoding and returns the encoding actually used
+func encodeBytes(b []byte, encoding string) (string, string) {
+	switch encoding {
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(b), EncodingBase64
+	case EncodingHex:
+		return hex.EncodeToString(b), EncodingHex
+	case EncodingAuto:
+		if !utf8.Valid(b) {
+			return base64.StdEncoding.EncodeToString(b), EncodingBase64
+		}
+	}
+	return string(b), EncodingNone
+}
+
+// decodeBytes reverses encodeBytes
+func decodeBytes(s, encoding string) ([]byte, error) {
+	switch encoding {
+	case EncodingNone, EncodingUTF8:
+		return []byte(s), nil
+	case EncodingBase64:
+		return base64.StdEncoding.DecodeString(s)
+	case EncodingHex:
+		return hex.DecodeString(s)
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q (expected %s, %s or %s)", encoding, EncodingUTF8, EncodingBase64, EncodingHex)
+	}
+}
+
+func timestampTypeName(t int8) string {
+	switch t {
+	case 0:
+		return TimestampTypeCreate
+	case 1:
+		return TimestampTypeLogAppend
+	default:
+		return TimestampTypeNotPresent
+	}
+}
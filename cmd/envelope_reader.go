@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// LineModeEnvelope reads MessageEnvelope objects (JSON array, single object or NDJSON)
+	LineModeEnvelope = "envelope"
+	// LineModeRaw turns every input line into the value of a record
+	LineModeRaw = "raw"
+)
+
+// messageReader streams message envelopes from an input. Next returns io.EOF
+// once the input is exhausted.
+type messageReader interface {
+	Next() (MessageEnvelope, error)
+}
+
+// newMessageReader returns a streaming reader for the given line mode.
+// topic is the destination of raw lines and is ignored in envelope mode.
+func newMessageReader(r io.Reader, lineMode, topic string) (messageReader, error) {
+	switch lineMode {
+	case LineModeEnvelope:
+		return newEnvelopeReader(r), nil
+	case LineModeRaw:
+		if topic == "" {
+			return nil, fmt.Errorf("a topic argument is required with --line-mode %s", LineModeRaw)
+		}
+		return &rawLineReader{r: bufio.NewReaderSize(r, 64*1024), topic: topic}, nil
+	default:
+		return nil, fmt.Errorf("unsupported line mode %q (expected %s or %s)", lineMode, LineModeEnvelope, LineModeRaw)
+	}
+}
+
+// envelopeReader decodes envelopes one at a time from a JSON array, a single
+// object or newline-delimited JSON, without loading the whole input in memory
+type envelopeReader struct {
+	br      *bufio.Reader
+	dec     *json.Decoder
+	inArray bool
+}
+
+func newEnvelopeReader(r io.Reader) *envelopeReader {
+	return &envelopeReader{br: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Next decodes the next envelope
+func (er *envelopeReader) Next() (MessageEnvelope, error) {
+	var env MessageEnvelope
+
+	if er.dec == nil {
+		first, err := peekNonSpace(er.br)
+		if err != nil {
+			return env, err
+		}
+		er.dec = json.NewDecoder(er.br)
+		if first == '[' {
+			if _, err := er.dec.Token(); err != nil {
+				return env, fmt.Errorf("failed to parse JSON: %w", err)
+			}
+			er.inArray = true
+		}
+	}
+
+	if er.inArray && !er.dec.More() {
+		if _, err := er.dec.Token(); err != nil {
+			return env, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return env, io.EOF
+	}
+
+	if err := er.dec.Decode(&env); err != nil {
+		if err == io.EOF {
+			return env, io.EOF
+		}
+		return env, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return env, nil
+}
+
+// peekNonSpace returns the first non whitespace byte without consuming it
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
+
+// rawLineReader turns every non empty line into a record value for topic
+type rawLineReader struct {
+	r     *bufio.Reader
+	topic string
+}
+
+// Next reads the next non empty line
+func (rr *rawLineReader) Next() (MessageEnvelope, error) {
+	for {
+		line, err := rr.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return MessageEnvelope{}, err
+		}
+		if err != nil && err != io.EOF {
+			return MessageEnvelope{}, err
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			if err == io.EOF {
+				return MessageEnvelope{}, io.EOF
+			}
+			continue
+		}
+
+		value := string(line)
+		return MessageEnvelope{Topic: rr.topic, Value: &value}, nil
+	}
+}
+
+// openInput opens path for reading, "-" meaning stdin
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	return f, nil
+}
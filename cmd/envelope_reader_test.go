@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, r messageReader) []MessageEnvelope {
+	t.Helper()
+	var envelopes []MessageEnvelope
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			return envelopes
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		envelopes = append(envelopes, env)
+	}
+}
+
+func TestEnvelopeReaderFormats(t *testing.T) {
+	inputs := map[string]string{
+		"array":  `[{"Topic":"a","Message":{"n":1}}, {"Topic":"b","Message":{"n":2}}]`,
+		"ndjson": "{\"Topic\":\"a\",\"Message\":{\"n\":1}}\n{\"Topic\":\"b\",\"Message\":{\"n\":2}}\n",
+		"pretty": "\n  {\n    \"Topic\": \"a\"\n  }\n{\"Topic\": \"b\"}",
+	}
+	for name, input := range inputs {
+		envelopes := readAll(t, newEnvelopeReader(strings.NewReader(input)))
+		if len(envelopes) != 2 || envelopes[0].Topic != "a" || envelopes[1].Topic != "b" {
+			t.Fatalf("%s: unexpected envelopes %+v", name, envelopes)
+		}
+	}
+}
+
+func TestEnvelopeReaderEmptyInput(t *testing.T) {
+	for _, input := range []string{"", "  \n", "[]"} {
+		if envelopes := readAll(t, newEnvelopeReader(strings.NewReader(input))); len(envelopes) != 0 {
+			t.Fatalf("expected no envelopes for %q, got %+v", input, envelopes)
+		}
+	}
+}
+
+func TestEnvelopeReaderReportsInvalidJSON(t *testing.T) {
+	r := newEnvelopeReader(strings.NewReader("{\"Topic\":\"a\"}\nnot json\n"))
+	if _, err := r.Next(); err != nil {
+		t.Fatalf("expected first envelope, got %v", err)
+	}
+	if _, err := r.Next(); err == nil || err == io.EOF {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestRawLineReader(t *testing.T) {
+	r, err := newMessageReader(strings.NewReader("first\r\n\nsecond {\"json\": true}\nlast"), LineModeRaw, "logs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	envelopes := readAll(t, r)
+	want := []string{"first", `second {"json": true}`, "last"}
+	if len(envelopes) != len(want) {
+		t.Fatalf("expected %d records, got %d", len(want), len(envelopes))
+	}
+	for i, env := range envelopes {
+		if env.Topic != "logs" || env.Value == nil || *env.Value != want[i] {
+			t.Fatalf("line %d: unexpected envelope %+v", i, env)
+		}
+	}
+}
+
+func TestRawLineReaderRequiresTopic(t *testing.T) {
+	if _, err := newMessageReader(strings.NewReader(""), LineModeRaw, ""); err == nil {
+		t.Fatalf("expected error without topic")
+	}
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+func testRecord() *kgo.Record {
+	return &kgo.Record{
+		Topic:       "events",
+		Partition:   3,
+		Offset:      42,
+		LeaderEpoch: 7,
+		Timestamp:   time.UnixMilli(1759935600123).UTC(),
+		Key:         []byte{0xff, 0x00, 0x01},
+		Value:       []byte{0x0a, 0x03, 'f', 'o', 'o'},
+		Headers: []kgo.RecordHeader{
+			{Key: "trace", Value: []byte("a")},
+			{Key: "trace", Value: []byte("b")},
+		},
+	}
+}
+
+func roundTrip(t *testing.T, env MessageEnvelope) MessageEnvelope {
+	t.Helper()
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+	var decoded MessageEnvelope
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal envelope: %v\n%s", err, data)
+	}
+	return decoded
+}
+
+func TestMessageEnvelopeRoundTripIsLossless(t *testing.T) {
+	original := testRecord()
+
+	for _, encoding := range []string{EncodingAuto, EncodingBase64, EncodingHex} {
+		env := roundTrip(t, newMessageEnvelope(original, encoding))
+		if *env.Offset != 42 || *env.LeaderEpoch != 7 || env.TimestampType != TimestampTypeCreate {
+			t.Fatalf("%s: metadata not preserved: %+v", encoding, env)
+		}
+
+		record, err := env.Record()
+		if err != nil {
+			t.Fatalf("%s: failed to build record: %v", encoding, err)
+		}
+		if record.Topic != original.Topic || record.Partition != original.Partition {
+			t.Fatalf("%s: expected %s/%d, got %s/%d", encoding, original.Topic, original.Partition, record.Topic, record.Partition)
+		}
+		if !record.Timestamp.Equal(original.Timestamp) {
+			t.Fatalf("%s: expected timestamp %v, got %v", encoding, original.Timestamp, record.Timestamp)
+		}
+		if !bytes.Equal(record.Key, original.Key) || !bytes.Equal(record.Value, original.Value) {
+			t.Fatalf("%s: key or value changed: %x/%x", encoding, record.Key, record.Value)
+		}
+		if len(record.Headers) != 2 || string(record.Headers[0].Value) != "a" || string(record.Headers[1].Value) != "b" {
+			t.Fatalf("%s: headers not preserved: %+v", encoding, record.Headers)
+		}
+	}
+}
+
+func TestNewMessageEnvelopeAutoKeepsJSONObjects(t *testing.T) {
+	record := &kgo.Record{Topic: "events", Key: []byte("user-1"), Value: []byte(`{"id":1}`)}
+
+	env := newMessageEnvelope(record, EncodingAuto)
+	if env.Message == nil || env.Value != nil {
+		t.Fatalf("expected JSON object value in Message, got %+v", env)
+	}
+	if env.Key == nil || *env.Key != "user-1" || env.KeyEncoding != EncodingNone {
+		t.Fatalf("expected plain text key, got %+v", env)
+	}
+}
+
+func TestNewMessageEnvelopeTombstone(t *testing.T) {
+	env := roundTrip(t, newMessageEnvelope(&kgo.Record{Topic: "events", Key: []byte("k")}, EncodingAuto))
+
+	record, err := env.Record()
+	if err != nil {
+		t.Fatalf("failed to build record: %v", err)
+	}
+	if record.Value != nil {
+		t.Fatalf("expected nil value for tombstone, got %q", record.Value)
+	}
+}
+
+func TestMessageEnvelopeRecordDefaults(t *testing.T) {
+	env := MessageEnvelope{Topic: "events", Message: json.RawMessage(`{ "a": 1 }`)}
+
+	record, err := env.Record()
+	if err != nil {
+		t.Fatalf("failed to build record: %v", err)
+	}
+	if record.Partition != kafka.UnassignedPartition {
+		t.Fatalf("expected unassigned partition, got %d", record.Partition)
+	}
+	if record.Key != nil || !record.Timestamp.IsZero() {
+		t.Fatalf("expected no key and no timestamp, got %+v", record)
+	}
+	if string(record.Value) != `{"a":1}` {
+		t.Fatalf("unexpected value %q", record.Value)
+	}
+}
+
+func TestEnvelopeHeadersKeepsObjectOrder(t *testing.T) {
+	var env MessageEnvelope
+	if err := json.Unmarshal([]byte(`{"Topic":"t","Headers":{"z":"1","a":"2"}}`), &env); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(env.Headers) != 2 || env.Headers[0].Key != "z" || env.Headers[1].Key != "a" {
+		t.Fatalf("expected headers in file order, got %+v", env.Headers)
+	}
+
+	data, err := json.Marshal(env.Headers)
+	if err != nil {
+		t.Fatalf("failed to marshal headers: %v", err)
+	}
+	if string(data) != `{"z":"1","a":"2"}` {
+		t.Fatalf("unexpected headers JSON %s", data)
+	}
+}
+
+func TestMessageEnvelopeValueSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload.bin")
+	if err := os.WriteFile(path, []byte{0x08, 0x96, 0x01}, 0o600); err != nil {
+		t.Fatalf("failed to write payload: %v", err)
+	}
+	text := "plain text"
+	blob := "CJYB"
+
+	cases := map[string]struct {
+		env  MessageEnvelope
+		want []byte
+	}{
+		"array message":  {MessageEnvelope{Message: json.RawMessage(`[1, 2]`)}, []byte(`[1,2]`)},
+		"string message": {MessageEnvelope{Message: json.RawMessage(`"hello"`)}, []byte(`"hello"`)},
+		"number message": {MessageEnvelope{Message: json.RawMessage(`42`)}, []byte(`42`)},
+		"value string":   {MessageEnvelope{ValueString: &text}, []byte(text)},
+		"value base64":   {MessageEnvelope{ValueBase64: &blob}, []byte{0x08, 0x96, 0x01}},
+		"value file":     {MessageEnvelope{ValueFile: path}, []byte{0x08, 0x96, 0x01}},
+		"null message":   {MessageEnvelope{Message: json.RawMessage(`null`)}, nil},
+	}
+	for name, tc := range cases {
+		tc.env.Topic = "events"
+		record, err := tc.env.Record()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !bytes.Equal(record.Value, tc.want) {
+			t.Fatalf("%s: expected %q, got %q", name, tc.want, record.Value)
+		}
+	}
+}
+
+func TestMessageEnvelopeRejectsSeveralValueSources(t *testing.T) {
+	text := "a"
+	env := MessageEnvelope{Topic: "events", Message: json.RawMessage(`{}`), ValueString: &text}
+	if _, err := env.Record(); err == nil {
+		t.Fatalf("expected error when several value sources are set")
+	}
+}
+
+func TestEnvelopeAcceptsAnyJSONMessage(t *testing.T) {
+	input := `[{"Topic":"a","Message":[1,2]},{"Topic":"a","Message":"text"},{"Topic":"a","Message":3.5}]`
+	envelopes := readAll(t, newEnvelopeReader(strings.NewReader(input)))
+	if len(envelopes) != 3 {
+		t.Fatalf("expected 3 envelopes, got %d", len(envelopes))
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// FormatJSON writes envelopes as a single indented JSON array
+	FormatJSON = "json"
+	// FormatNDJSON writes one compact JSON envelope per line
+	FormatNDJSON = "ndjson"
+)
+
+const (
+	defaultFlushEvery    = 1000
+	defaultFlushInterval = time.Second
+)
+
+// envelopeWriter streams message envelopes to an output as soon as they are read,
+// so extraction runs in constant memory and a failure keeps what was already written.
+// It is safe for concurrent use.
+type envelopeWriter struct {
+	mu            sync.Mutex
+	w             *bufio.Writer
+	closer        io.Closer
+	array         bool
+	count         int64
+	pending       int
+	lastFlush     time.Time
+	flushEvery    int
+	flushInterval time.Duration
+}
+
+// newEnvelopeWriter creates a streaming writer for the given format (json or ndjson).
+// The underlying writer is closed by Close when it implements io.Closer.
+func newEnvelopeWriter(w io.Writer, format string) (*envelopeWriter, error) {
+	ew := &envelopeWriter{
+		w:             bufio.NewWriterSize(w, 64*1024),
+		lastFlush:     time.Now(),
+		flushEvery:    defaultFlushEvery,
+		flushInterval: defaultFlushInterval,
+	}
+	if c, ok := w.(io.Closer); ok {
+		ew.closer = c
+	}
+
+	switch format {
+	case FormatJSON:
+		ew.array = true
+		if _, err := ew.w.WriteString("["); err != nil {
+			return nil, err
+		}
+	case FormatNDJSON:
+	default:
+		return nil, fmt.Errorf("unsupported output format %q (expected %s or %s)", format, FormatJSON, FormatNDJSON)
+	}
+
+	return ew, nil
+}
+
+// Write encodes a single envelope and flushes periodically
+func (ew *envelopeWriter) Write(env MessageEnvelope) error {
+	var (
+		data []byte
+		err  error
+	)
+	if ew.array {
+		data, err = json.MarshalIndent(env, "  ", "  ")
+	} else {
+		data, err = json.Marshal(env)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+
+	if ew.array {
+		sep := ",\n  "
+		if ew.count == 0 {
+			sep = "\n  "
+		}
+		if _, err := ew.w.WriteString(sep); err != nil {
+			return err
+		}
+		if _, err := ew.w.Write(data); err != nil {
+			return err
+		}
+	} else {
+		if _, err := ew.w.Write(data); err != nil {
+			return err
+		}
+		if err := ew.w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+
+	ew.count++
+	ew.pending++
+	if ew.pending >= ew.flushEvery || time.Since(ew.lastFlush) >= ew.flushInterval {
+		return ew.flushLocked()
+	}
+	return nil
+}
+
+// Count returns the number of envelopes written so far
+func (ew *envelopeWriter) Count() int64 {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	return ew.count
+}
+
+// Close terminates the JSON array if needed, flushes and closes the output
+func (ew *envelopeWriter) Close() error {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+
+	if ew.array {
+		closing := "\n]\n"
+		if ew.count == 0 {
+			closing = "]\n"
+		}
+		if _, err := ew.w.WriteString(closing); err != nil {
+			return err
+		}
+	}
+	if err := ew.flushLocked(); err != nil {
+		return err
+	}
+	if ew.closer != nil {
+		return ew.closer.Close()
+	}
+	return nil
+}
+
+func (ew *envelopeWriter) flushLocked() error {
+	ew.pending = 0
+	ew.lastFlush = time.Now()
+	if err := ew.w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEnvelopeWriterJSONArray(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newEnvelopeWriter(&buf, FormatJSON)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := w.Write(MessageEnvelope{Topic: "events"}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	var decoded []MessageEnvelope
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not a valid JSON array: %v\n%s", err, buf.String())
+	}
+	if len(decoded) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(decoded))
+	}
+}
+
+func TestEnvelopeWriterEmptyJSONArray(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newEnvelopeWriter(&buf, FormatJSON)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Fatalf("expected empty array, got %q", buf.String())
+	}
+}
+
+func TestEnvelopeWriterNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newEnvelopeWriter(&buf, FormatNDJSON)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	w.flushEvery = 1
+	if err := w.Write(MessageEnvelope{Topic: "a"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	// Flushed records must be visible before Close
+	if !strings.HasSuffix(buf.String(), "\n") {
+		t.Fatalf("expected record to be flushed, got %q", buf.String())
+	}
+	if err := w.Write(MessageEnvelope{Topic: "b"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var env MessageEnvelope
+		if err := json.Unmarshal([]byte(line), &env); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", line, err)
+		}
+	}
+}
+
+func TestNewEnvelopeWriterRejectsUnknownFormat(t *testing.T) {
+	if _, err := newEnvelopeWriter(&bytes.Buffer{}, "xml"); err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+}
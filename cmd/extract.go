@@ -2,23 +2,33 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
 
 	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
 )
 
+const defaultExtractOutput = "extracted_messages.json"
+
 var (
-	topic   string
-	fromStr string
-	toStr   string
-	output  string
+	topic       string
+	fromStr     string
+	toStr       string
+	output      string
+	format      string
+	encoding    string
+	concurrency int
+	filterExpr  string
+	noDecode    bool
 )
 
 var extractCmd = &cobra.Command{
@@ -26,12 +36,34 @@ var extractCmd = &cobra.Command{
 	Short: "Extract messages from a Kafka topic to a file",
 	Long: `Extract messages from a specified Kafka topic within an optional time range and save them to a file.
 You can specify the time range using --from and --to flags in RFC3339 format.
-The output file can be specified with the --output flag. If not provided, it defaults to 'extracted_messages.json'.`,
+The output file can be specified with the --output flag. If not provided, it defaults to 'extracted_messages.json'.
+Every partition of the topic is read up to its own end offset; use --concurrency to control how many
+partitions are read in parallel.
+Messages are streamed to the file as they are read, either as a JSON array (--format json, default)
+or as newline-delimited JSON (--format ndjson).
+Each message keeps its key, partition, offset, timestamp and headers. With --encoding auto (default),
+JSON object and array values are written as Message and other values as text or base64; use --encoding base64
+or hex for a byte-exact copy that produce -i can replay.
+Use --filter to only keep the messages matching an expression.
+` + filterHelp + schemaHelp,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		defaultWindows := 15 // 15 minutes
 		if topic == "" {
 			return fmt.Errorf("Topic input in mandatory")
 		}
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		if format != FormatJSON && format != FormatNDJSON {
+			return fmt.Errorf("unsupported --format %q (expected %s or %s)", format, FormatJSON, FormatNDJSON)
+		}
+		if encoding != EncodingAuto && encoding != EncodingBase64 && encoding != EncodingHex {
+			return fmt.Errorf("unsupported --encoding %q (expected %s, %s or %s)", encoding, EncodingAuto, EncodingBase64, EncodingHex)
+		}
+		filter, err := parseFilter(filterExpr)
+		if err != nil {
+			return err
+		}
 		if fromStr == "" || toStr == "" {
 			color.HiYellow("fromStr or toStr undefined, backup to default values: %d minutes", defaultWindows)
 			fromStr = time.Now().Add(-time.Duration(defaultWindows) * time.Minute).Format(time.RFC3339)
@@ -53,6 +85,12 @@ The output file can be specified with the --output flag. If not provided, it def
 
 		cfg := kafka.LoadConfig()
 		ctx := context.Background()
+		decoder, err := newRecordDecoder(cfg, noDecode, func(format string, args ...any) {
+			color.Yellow(format, args...)
+		})
+		if err != nil {
+			return err
+		}
 
 		// 1️⃣ Create admin client using utility function
 		client, adminClient, err := cfg.NewAdminClient()
@@ -76,7 +114,7 @@ The output file can be specified with the --output flag. If not provided, it def
 			return fmt.Errorf("topic %s has no partitions", topic)
 		}
 
-		// Get earliest and latest offsets for partition 0 using timestamp lookups
+		// Get earliest and latest offsets for every partition using timestamp lookups
 		earliestOffsets, err := adminClient.ListOffsetsAfterMilli(ctx, 0, topic) // 0 = earliest
 		if err != nil {
 			return fmt.Errorf("failed to get earliest offsets: %w", err)
@@ -87,160 +125,374 @@ The output file can be specified with the --output flag. If not provided, it def
 			return fmt.Errorf("failed to get latest offsets: %w", err)
 		}
 
-		var firstOffset, lastOffset int64
-		if offsets, exists := earliestOffsets[topic]; exists {
-			if partOffset, partExists := offsets[0]; partExists {
-				firstOffset = partOffset.Offset
-			}
+		// 3️⃣ Try time-based offset lookup with proper error handling
+		color.Blue("🕐 Finding start offsets for time: %s", fromStr)
+		startOffsets, err := adminClient.ListOffsetsAfterMilli(ctx, from.UnixMilli(), topic)
+		if err != nil {
+			color.Yellow("⚠️  Could not find offsets for start time, using first offsets")
+			startOffsets = nil
 		}
 
-		if offsets, exists := latestOffsets[topic]; exists {
-			if partOffset, partExists := offsets[0]; partExists {
-				lastOffset = partOffset.Offset
-			}
+		color.Blue("🕐 Finding end offsets for time: %s", toStr)
+		endOffsets, err := adminClient.ListOffsetsAfterMilli(ctx, to.UnixMilli(), topic)
+		if err != nil {
+			color.Yellow("⚠️  Could not find offsets for end time, using last offsets")
+			endOffsets = nil
 		}
 
-		color.Cyan("📊 Topic %s partition 0: %d (first) → %d (last)", topic, firstOffset, lastOffset)
-
-		if firstOffset >= lastOffset {
-			color.Yellow("⚠️  No messages found in topic %s", topic)
-			return fmt.Errorf("no messages found in topic")
-		}
+		ranges := resolvePartitionRanges(topic, topicInfo.Partitions.Numbers(),
+			earliestOffsets, latestOffsets, startOffsets, endOffsets)
 
-		// 3️⃣ Try time-based offset lookup with proper error handling
-		color.Blue("🕐 Finding start offset for time: %s", fromStr)
-		startOffsetResults, err := adminClient.ListOffsetsAfterMilli(ctx, from.UnixMilli(), topic)
-		var startOffset int64 = firstOffset
-		if err == nil && startOffsetResults != nil {
-			if offsets, exists := startOffsetResults[topic]; exists {
-				if partOffset, partExists := offsets[0]; partExists {
-					startOffset = partOffset.Offset
-				}
-			}
-		} else {
-			color.Yellow("⚠️  Could not find offset for start time, using first offset")
-		}
-
-		color.Blue("🕐 Finding end offset for time: %s", toStr)
-		endOffsetResults, err := adminClient.ListOffsetsAfterMilli(ctx, to.UnixMilli(), topic)
-		var endOffset int64 = lastOffset
-		if err == nil && endOffsetResults != nil {
-			if offsets, exists := endOffsetResults[topic]; exists {
-				if partOffset, partExists := offsets[0]; partExists {
-					endOffset = partOffset.Offset
-				}
-			}
-		} else {
-			color.Yellow("⚠️  Could not find offset for end time, using last offset")
+		var expectedMessages int64
+		for _, r := range ranges {
+			color.Cyan("📊 Partition %d: %d → %d (%d messages)", r.Partition, r.Start, r.End, r.Count())
+			expectedMessages += r.Count()
 		}
 
-		color.Cyan("📊 Time-based offset range: %d → %d", startOffset, endOffset)
-
-		if startOffset >= endOffset {
+		if expectedMessages == 0 {
 			color.Yellow("⚠️  No messages in the specified time range")
 			return fmt.Errorf("no messages found in time range %s to %s", fromStr, toStr)
 		}
 
-		messageCount := endOffset - startOffset
-		color.Green("🎯 Will read approximately %d messages", messageCount)
+		color.Green("🎯 Will read approximately %d messages from %d partitions", expectedMessages, len(ranges))
 
-		// 3️⃣ Create partition consumer using utility function
-		consumerClient, err := cfg.NewPartitionConsumerClient(topic, 0, startOffset)
+		if output == "" {
+			output = defaultExtractOutput
+		}
+		file, err := os.Create(output)
 		if err != nil {
-			return fmt.Errorf("failed to create consumer client: %w", err)
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		writer, err := newEnvelopeWriter(file, format)
+		if err != nil {
+			_ = file.Close()
+			return err
 		}
-		defer consumerClient.Close()
 
-		color.Blue("✅ Consumer positioned at offset %d", startOffset)
+		// 4️⃣ Stream every partition up to its own end offset, several at a time
+		counts, err := extractPartitions(ctx, cfg, topic, ranges, concurrency, decoder, filter, writer)
+		if closeErr := writer.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to write output file: %w", closeErr)
+		}
+		if err != nil {
+			color.Yellow("⚠️  Partial extraction: %d messages written to %s", writer.Count(), output)
+			return err
+		}
 
-		// 4️⃣ Read messages until endOffset (no timestamp filtering needed)
-		var messages []MessageEnvelope
-		readCount := 0
-		expectedMessages := endOffset - startOffset
+		for i, r := range ranges {
+			color.Blue("📦 Partition %d: %d/%d messages extracted", r.Partition, counts[i], r.Count())
+		}
+		if filter != nil {
+			color.Blue("🔎 Filter: %s", filter)
+		}
+		color.Blue("📊 Total messages extracted: %d", writer.Count())
+		color.Green("✅ Extracted %d messages → %s", writer.Count(), output)
+		return nil
+	},
+}
 
-		color.Cyan("🔍 Reading %d messages from offset %d to %d...", expectedMessages, startOffset, endOffset)
+// partitionRange is the half-open offset range [Start, End) to read from a partition
+type partitionRange struct {
+	Partition int32
+	Start     int64
+	End       int64
+}
+
+// Count returns the number of offsets covered by the range
+func (r partitionRange) Count() int64 {
+	if r.End <= r.Start {
+		return 0
+	}
+	return r.End - r.Start
+}
 
-		// Set a reasonable timeout based on expected message count
-		timeoutDuration := 10*time.Second + time.Duration(expectedMessages)*time.Millisecond
-		readCtx, cancel := context.WithTimeout(ctx, timeoutDuration)
-		defer cancel()
+// resolvePartitionRanges computes the offset range to read for each partition.
+// Time-based offsets take precedence; when a lookup is missing or failed for a
+// partition, its earliest or latest offset is used instead. Partitions are
+// expected in ascending order, as returned by kadm.PartitionDetails.Numbers.
+func resolvePartitionRanges(topic string, partitions []int32, earliest, latest, start, end kadm.ListedOffsets) []partitionRange {
+	ranges := make([]partitionRange, 0, len(partitions))
+	for _, p := range partitions {
+		first, _ := lookupOffset(earliest, topic, p)
+		last, _ := lookupOffset(latest, topic, p)
+
+		r := partitionRange{Partition: p, Start: first, End: last}
+		if o, ok := lookupOffset(start, topic, p); ok && o >= 0 {
+			r.Start = o
+		}
+		if o, ok := lookupOffset(end, topic, p); ok && o >= 0 {
+			r.End = o
+		}
+		// Clamp to what actually exists in the log
+		if r.Start < first {
+			r.Start = first
+		}
+		if r.End > last {
+			r.End = last
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+func lookupOffset(offsets kadm.ListedOffsets, topic string, partition int32) (int64, bool) {
+	o, ok := offsets.Lookup(topic, partition)
+	if !ok || o.Err != nil {
+		return 0, false
+	}
+	return o.Offset, true
+}
+
+// extractPartitions streams the decoded messages of all ranges matching filter to writer using
+// at most concurrency partition consumers at once. The number of messages written per
+// range is returned in the same order as ranges.
+func extractPartitions(ctx context.Context, cfg *kafka.Config, topic string, ranges []partitionRange, concurrency int, decoder *recordDecoder, filter *recordFilter, writer *envelopeWriter) ([]int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	progress := newExtractProgress(ranges)
+	stopProgress := progress.start(2 * time.Second)
+	defer stopProgress()
+
+	counts := make([]int64, len(ranges))
+	errs := make([]error, len(ranges))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, r := range ranges {
+		if r.Count() == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, r partitionRange) {
+			defer wg.Done()
 
-		reachedEnd := false
-		for !reachedEnd {
 			select {
-			case <-readCtx.Done():
-				color.Yellow("📝 Finished reading: timeout reached")
-				reachedEnd = true
-				continue
-			default:
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
 			}
-
-			fetches := consumerClient.PollFetches(readCtx)
-			if errs := fetches.Errors(); len(errs) > 0 {
-				for _, err := range errs {
-					if err.Err.Error() != "context deadline exceeded" {
-						color.Red("fetch error: %v", err)
-					}
-				}
-				continue
+			defer func() { <-sem }()
+
+			count, err := extractPartition(ctx, cfg, topic, r, decoder, filter, writer, progress)
+			counts[i] = count
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to extract partition %d: %w", r.Partition, err)
+				cancel()
+				return
 			}
+		}(i, r)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return counts, err
+		}
+	}
+	return counts, nil
+}
 
-			if fetches.Empty() {
-				continue
+// extractPartition streams the messages of a single partition from r.Start up to
+// (excluding) r.End that match filter to writer and returns the number of messages written.
+// It fails when the end offset is not reached before the timeout.
+func extractPartition(ctx context.Context, cfg *kafka.Config, topic string, r partitionRange, decoder *recordDecoder, filter *recordFilter, writer *envelopeWriter, progress *extractProgress) (int64, error) {
+	consumerClient, err := cfg.NewPartitionConsumerClient(topic, int(r.Partition), r.Start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create consumer client: %w", err)
+	}
+	defer consumerClient.Close()
+
+	expectedMessages := r.Count()
+	color.Cyan("🔍 Partition %d: reading %d messages from offset %d to %d...", r.Partition, expectedMessages, r.Start, r.End)
+
+	// Set a reasonable timeout based on expected message count
+	timeoutDuration := 10*time.Second + time.Duration(expectedMessages)*time.Millisecond
+	readCtx, cancel := context.WithTimeout(ctx, timeoutDuration)
+	defer cancel()
+
+	reader := &partitionReader{r: r, next: r.Start, decoder: decoder, filter: filter, writer: writer, progress: progress}
+	for !reader.done() {
+		if readCtx.Err() != nil {
+			if ctx.Err() != nil {
+				return reader.written, ctx.Err()
 			}
+			return reader.written, fmt.Errorf("timed out after %s at offset %d, before end offset %d", timeoutDuration, reader.next, r.End)
+		}
+		if err := reader.process(ctx, consumerClient.PollFetches(readCtx)); err != nil {
+			return reader.written, err
+		}
+	}
 
-			// Process records
-			fetches.EachPartition(func(p kgo.FetchTopicPartition) {
-				for _, record := range p.Records {
-					readCount++
-					if readCount%1000 == 0 || readCount <= 10 {
-						color.Blue("📊 Read message %d/%d at offset %d", readCount, expectedMessages, record.Offset)
-					}
-
-					if record.Offset >= endOffset {
-						color.Blue("🛑 Reached end offset %d", endOffset)
-						reachedEnd = true
-						return
-					}
-
-					// Convert headers
-					headers := make(map[string]string)
-					for _, h := range record.Headers {
-						headers[h.Key] = string(h.Value)
-					}
-
-					// Parse message body
-					var body map[string]interface{}
-					if jsonErr := json.Unmarshal(record.Value, &body); jsonErr != nil {
-						body = map[string]interface{}{"raw": string(record.Value)}
-					}
-
-					messages = append(messages, MessageEnvelope{
-						Topic:   topic,
-						Headers: headers,
-						Message: body,
-					})
-				}
-			})
-		}
-
-		color.Blue("📊 Total messages extracted: %d", len(messages))
-		// write to JSON file
-		file, err := os.Create(output)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
+	progress.finish(r.Partition)
+	color.Blue("🛑 Partition %d: reached end offset %d", r.Partition, r.End)
+	return reader.written, nil
+}
+
+// partitionReader writes the fetched records of a partition range
+type partitionReader struct {
+	r        partitionRange
+	next     int64 // offset following the last record read
+	caughtUp bool  // next reached the high watermark, nothing more to read
+	written  int64
+
+	decoder  *recordDecoder
+	filter   *recordFilter
+	writer   *envelopeWriter
+	progress *extractProgress
+}
+
+// done reports whether the whole range has been read
+func (pr *partitionReader) done() bool {
+	return pr.next >= pr.r.End || pr.caughtUp
+}
+
+// process writes the records of a poll, then fails on the first fetch error
+// that polling again won't clear. Other errors are only reported.
+func (pr *partitionReader) process(ctx context.Context, fetches kgo.Fetches) error {
+	var fetchErr error
+	fetches.EachError(func(_ string, _ int32, err error) {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+		case isFatalFetchError(err):
+			if fetchErr == nil {
+				fetchErr = fmt.Errorf("fetch failed at offset %d: %w", pr.next, err)
+			}
+		default:
+			color.Red("fetch error: %v", err)
 		}
-		defer file.Close()
+	})
 
-		enc := json.NewEncoder(file)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(messages); err != nil {
-			return fmt.Errorf("failed to encode messages: %w", err)
+	var writeErr error
+	fetches.EachPartition(func(fp kgo.FetchTopicPartition) {
+		for _, record := range fp.Records {
+			if writeErr != nil || pr.done() {
+				return
+			}
+			writeErr = pr.read(ctx, record)
 		}
+		// The range ends past the log, e.g. after a truncation
+		if len(fp.Records) > 0 && pr.next >= fp.HighWatermark {
+			pr.caughtUp = true
+		}
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+	return fetchErr
+}
 
-		color.Green("✅ Extracted %d messages → %s", len(messages), output)
+// read writes record if it is in the range and matches the filter
+func (pr *partitionReader) read(ctx context.Context, record *kgo.Record) error {
+	if record.Offset >= pr.r.End {
+		pr.next = record.Offset
 		return nil
-	},
+	}
+	pr.next = record.Offset + 1
+	pr.progress.add(pr.r.Partition, 1)
+	// Transaction markers take an offset but hold no message
+	if record.Attrs.IsControl() {
+		return nil
+	}
+
+	pr.decoder.Decode(ctx, record)
+	if !pr.filter.Match(record) {
+		return nil
+	}
+	if err := pr.writer.Write(newMessageEnvelope(record, encoding)); err != nil {
+		return err
+	}
+	pr.written++
+	return nil
+}
+
+// isFatalFetchError reports whether a fetch error won't clear by polling again,
+// e.g. an offset out of range or an authorization failure
+func isFatalFetchError(err error) bool {
+	var kafkaErr *kerr.Error
+	return errors.As(err, &kafkaErr) && !kafkaErr.Retriable
+}
+
+// extractProgress tracks how many records have been read from each partition
+type extractProgress struct {
+	mu       sync.Mutex
+	order    []int32
+	read     map[int32]int64
+	expected map[int32]int64
+	done     map[int32]bool
+}
+
+func newExtractProgress(ranges []partitionRange) *extractProgress {
+	p := &extractProgress{
+		read:     make(map[int32]int64, len(ranges)),
+		expected: make(map[int32]int64, len(ranges)),
+		done:     make(map[int32]bool, len(ranges)),
+	}
+	for _, r := range ranges {
+		p.order = append(p.order, r.Partition)
+		p.expected[r.Partition] = r.Count()
+	}
+	return p
+}
+
+func (p *extractProgress) add(partition int32, n int64) {
+	p.mu.Lock()
+	p.read[partition] += n
+	p.mu.Unlock()
+}
+
+func (p *extractProgress) finish(partition int32) {
+	p.mu.Lock()
+	p.done[partition] = true
+	p.mu.Unlock()
+}
+
+// start prints the progress of partitions being read at every interval until
+// the returned function is called
+func (p *extractProgress) start(interval time.Duration) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				p.print()
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+func (p *extractProgress) print() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var totalRead, totalExpected int64
+	for _, partition := range p.order {
+		read, expected := p.read[partition], p.expected[partition]
+		totalRead += read
+		totalExpected += expected
+		if read > 0 && !p.done[partition] {
+			color.Blue("⏳ Partition %d: %d/%d messages (%d%%)", partition, read, expected, percent(read, expected))
+		}
+	}
+	color.Cyan("⏳ Total: %d/%d messages (%d%%)", totalRead, totalExpected, percent(totalRead, totalExpected))
+}
+
+func percent(n, total int64) int64 {
+	if total == 0 {
+		return 100
+	}
+	return n * 100 / total
 }
 
 // parseTimeWithTimezone parses time strings with flexible timezone support
@@ -278,4 +530,9 @@ func init() {
 	extractCmd.Flags().StringVarP(&fromStr, "from", "", "", "Start time (RFC3339 format with timezone or local time without timezone)")
 	extractCmd.Flags().StringVarP(&toStr, "to", "", "", "End time (RFC3339 format with timezone or local time without timezone)")
 	extractCmd.Flags().StringVarP(&output, "output", "o", "", "Optional output file")
+	extractCmd.Flags().StringVarP(&format, "format", "f", FormatJSON, "Output format: json (array) or ndjson (one message per line)")
+	extractCmd.Flags().StringVarP(&encoding, "encoding", "e", EncodingAuto, "Encoding of keys, values and header values: auto, base64 or hex (byte-exact)")
+	extractCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Number of partitions to read in parallel")
+	extractCmd.Flags().StringVar(&filterExpr, "filter", "", `Only write messages matching this expression, e.g. '.user.id == 42'`)
+	extractCmd.Flags().BoolVar(&noDecode, "no-decode", false, "Don't decode Schema Registry encoded keys and values")
 }
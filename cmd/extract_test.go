@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func listedOffsets(topic string, offsets map[int32]int64) kadm.ListedOffsets {
+	l := kadm.ListedOffsets{topic: {}}
+	for p, o := range offsets {
+		l[topic][p] = kadm.ListedOffset{Topic: topic, Partition: p, Offset: o}
+	}
+	return l
+}
+
+func TestResolvePartitionRanges(t *testing.T) {
+	const topic = "events"
+	earliest := listedOffsets(topic, map[int32]int64{0: 0, 1: 10, 2: 5})
+	latest := listedOffsets(topic, map[int32]int64{0: 100, 1: 50, 2: 5})
+	start := listedOffsets(topic, map[int32]int64{0: 40, 1: 50})
+	end := listedOffsets(topic, map[int32]int64{0: 60, 1: 50})
+
+	ranges := resolvePartitionRanges(topic, []int32{0, 1, 2}, earliest, latest, start, end)
+	if len(ranges) != 3 {
+		t.Fatalf("expected 3 ranges, got %d", len(ranges))
+	}
+
+	want := []partitionRange{
+		{Partition: 0, Start: 40, End: 60},
+		{Partition: 1, Start: 50, End: 50},
+		{Partition: 2, Start: 5, End: 5},
+	}
+	for i, r := range ranges {
+		if r != want[i] {
+			t.Fatalf("partition %d: expected %+v, got %+v", i, want[i], r)
+		}
+	}
+	if ranges[0].Count() != 20 || ranges[1].Count() != 0 {
+		t.Fatalf("unexpected counts: %d, %d", ranges[0].Count(), ranges[1].Count())
+	}
+}
+
+func TestResolvePartitionRangesFallsBackWithoutTimeLookups(t *testing.T) {
+	const topic = "events"
+	earliest := listedOffsets(topic, map[int32]int64{0: 3})
+	latest := listedOffsets(topic, map[int32]int64{0: 9})
+
+	ranges := resolvePartitionRanges(topic, []int32{0}, earliest, latest, nil, nil)
+	if len(ranges) != 1 || ranges[0].Start != 3 || ranges[0].End != 9 {
+		t.Fatalf("expected range 3 → 9, got %+v", ranges)
+	}
+}
+
+// newTestPartitionReader returns a reader of r writing NDJSON to out
+func newTestPartitionReader(t *testing.T, r partitionRange, out *bytes.Buffer) *partitionReader {
+	t.Helper()
+	writer, err := newEnvelopeWriter(out, FormatNDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &partitionReader{r: r, next: r.Start, writer: writer, progress: newExtractProgress([]partitionRange{r})}
+}
+
+// partitionFetch returns a poll of partition 0 with the given records and error
+func partitionFetch(highWatermark int64, err error, offsets ...int64) kgo.Fetches {
+	fp := kgo.FetchPartition{Partition: 0, HighWatermark: highWatermark, Err: err}
+	for _, o := range offsets {
+		fp.Records = append(fp.Records, &kgo.Record{Topic: "events", Offset: o, Value: []byte("v")})
+	}
+	return kgo.Fetches{{Topics: []kgo.FetchTopic{{Topic: "events", Partitions: []kgo.FetchPartition{fp}}}}}
+}
+
+func TestPartitionReaderStopsAtEndOffset(t *testing.T) {
+	var out bytes.Buffer
+	reader := newTestPartitionReader(t, partitionRange{Start: 2, End: 5}, &out)
+
+	if err := reader.process(context.Background(), partitionFetch(10, nil, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if reader.done() {
+		t.Fatal("done before the end offset")
+	}
+	// Offset 4 was compacted away
+	if err := reader.process(context.Background(), partitionFetch(10, nil, 5, 6)); err != nil {
+		t.Fatal(err)
+	}
+	if !reader.done() || reader.written != 2 {
+		t.Fatalf("done = %v, written = %d", reader.done(), reader.written)
+	}
+	if err := reader.writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out.String(), "\n"); n != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", n, out.String())
+	}
+}
+
+func TestPartitionReaderStopsAtHighWatermark(t *testing.T) {
+	var out bytes.Buffer
+	reader := newTestPartitionReader(t, partitionRange{Start: 0, End: 5}, &out)
+
+	if err := reader.process(context.Background(), partitionFetch(3, nil, 0, 1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if !reader.done() || reader.written != 3 {
+		t.Fatalf("done = %v, written = %d", reader.done(), reader.written)
+	}
+}
+
+func TestPartitionReaderFetchErrors(t *testing.T) {
+	var out bytes.Buffer
+	reader := newTestPartitionReader(t, partitionRange{Start: 0, End: 5}, &out)
+
+	// Records of a fetch with an error are still written
+	err := reader.process(context.Background(), partitionFetch(10, kerr.OffsetOutOfRange, 0, 1))
+	if !errors.Is(err, kerr.OffsetOutOfRange) {
+		t.Fatalf("expected OFFSET_OUT_OF_RANGE, got %v", err)
+	}
+	if reader.written != 2 {
+		t.Fatalf("written = %d", reader.written)
+	}
+
+	if err := reader.process(context.Background(), partitionFetch(10, context.DeadlineExceeded)); err != nil {
+		t.Fatalf("context errors are not fetch failures: %v", err)
+	}
+	if err := reader.process(context.Background(), partitionFetch(10, kerr.NotLeaderForPartition, 2)); err != nil {
+		t.Fatalf("retriable errors are not fetch failures: %v", err)
+	}
+	if reader.next != 3 {
+		t.Fatalf("next = %d", reader.next)
+	}
+}
+
+func TestIsFatalFetchError(t *testing.T) {
+	tests := []struct {
+		err   error
+		fatal bool
+	}{
+		{kerr.OffsetOutOfRange, true},
+		{kerr.TopicAuthorizationFailed, true},
+		{kerr.NotLeaderForPartition, false},
+		{context.DeadlineExceeded, false},
+		{errors.New("connection reset"), false},
+	}
+	for _, tt := range tests {
+		if got := isFatalFetchError(tt.err); got != tt.fatal {
+			t.Errorf("isFatalFetchError(%v) = %v, want %v", tt.err, got, tt.fatal)
+		}
+	}
+}
@@ -0,0 +1,653 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// filterHelp documents the --filter syntax in the help of the commands using it
+const filterHelp = `
+Filter expressions (--filter) compare a field with a literal and combine
+comparisons with and, or, not and parentheses:
+
+  .user.id == 42 and headers.source == "web"
+  key =~ "^order-" or (offset >= 1000 and timestamp < "2025-10-08T16:00:00Z")
+
+Fields: .path (JSON path into the value, e.g. .items[0].sku), key, value, topic,
+partition, offset, timestamp and headers.name. Operators: == != < <= > >=,
+=~ and !~ (regular expressions) and contains.`
+
+// recordFilter is a compiled --filter expression, shared by consume and extract
+// (syntax in filterHelp).
+//
+// Besides .path segments, object keys can be quoted (.["a b"]) and headers
+// indexed (headers["x-id"]); && || ! are accepted for and/or/not. A field on
+// its own is true when it is present and not false or null. Missing fields and
+// values that are not JSON evaluate to null. Timestamps compare with a time
+// string (same formats as extract) or Unix milliseconds.
+type recordFilter struct {
+	expr string
+	root filterNode
+}
+
+// parseFilter compiles expr. An empty expression returns a nil filter that matches everything.
+func parseFilter(expr string) (*recordFilter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %s", p.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	return &recordFilter{expr: expr, root: root}, nil
+}
+
+// Match reports whether the record is kept by the filter
+func (f *recordFilter) Match(r *kgo.Record) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.eval(&filterRecord{record: r})
+}
+
+// String returns the source expression
+func (f *recordFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+// filterRecord is the record being evaluated, with its value decoded as JSON at most once
+type filterRecord struct {
+	record *kgo.Record
+	parsed bool
+	doc    any
+}
+
+func (fr *filterRecord) document() any {
+	if !fr.parsed {
+		fr.parsed = true
+		if err := json.Unmarshal(fr.record.Value, &fr.doc); err != nil {
+			fr.doc = nil
+		}
+	}
+	return fr.doc
+}
+
+// Lexer
+
+type filterTokenKind int
+
+const (
+	tokEOF filterTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokDot
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+func (t filterToken) String() string {
+	if t.kind == tokEOF {
+		return "end of filter"
+	}
+	return fmt.Sprintf("%q at position %d", t.text, t.pos+1)
+}
+
+func lexFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']' || c == '.':
+			kind := map[rune]filterTokenKind{'(': tokLParen, ')': tokRParen, '[': tokLBracket, ']': tokRBracket, '.': tokDot}[c]
+			tokens = append(tokens, filterToken{kind: kind, text: string(c), pos: i})
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != c {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			raw := string(runes[start+1 : i])
+			i++
+			text, err := unquoteFilterString(raw, c)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %w", start+1, err)
+			}
+			tokens = append(tokens, filterToken{kind: tokString, text: text, pos: start})
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])) {
+				// A sign is only part of the number right after an exponent
+				if (runes[i] == '+' || runes[i] == '-') && runes[i-1] != 'e' && runes[i-1] != 'E' {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokNumber, text: string(runes[start:i]), pos: start})
+		case isFilterIdentRune(c, true):
+			start := i
+			for i < len(runes) && isFilterIdentRune(runes[i], false) {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokIdent, text: string(runes[start:i]), pos: start})
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", ">=", "<=", "=~", "!~", "&&", "||", ">", "<", "!"} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at position %d", c, i+1)
+			}
+			tokens = append(tokens, filterToken{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, filterToken{kind: tokEOF, pos: len(runes)}), nil
+}
+
+func isFilterIdentRune(c rune, first bool) bool {
+	if unicode.IsLetter(c) || c == '_' {
+		return true
+	}
+	return !first && (unicode.IsDigit(c) || c == '-')
+}
+
+// unquoteFilterString resolves escapes: double quoted strings follow Go rules,
+// single quoted strings only escape the quote itself, which keeps regexes readable
+func unquoteFilterString(raw string, quote rune) (string, error) {
+	if quote == '"' {
+		return strconv.Unquote(`"` + raw + `"`)
+	}
+	return strings.ReplaceAll(raw, `\'`, `'`), nil
+}
+
+// Parser
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken { return p.tokens[p.pos] }
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword reports whether the next token is one of the given words or operators
+func (p *filterParser) isKeyword(words ...string) bool {
+	t := p.peek()
+	if t.kind != tokIdent && t.kind != tokOp {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and", "&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.isKeyword("not", "!") {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	if p.peek().kind == tokLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, fmt.Errorf("expected ) but found %s", t)
+		}
+		return inner, nil
+	}
+
+	field, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.peek()
+	isComparison := op.kind == tokOp && op.text != "&&" && op.text != "||" && op.text != "!"
+	if !isComparison && !p.isKeyword("contains") {
+		return truthyNode{field}, nil
+	}
+	p.next()
+
+	literal, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	return newCompareNode(field, strings.ToLower(op.text), literal)
+}
+
+// parseField parses a JSON path or a record field name
+func (p *filterParser) parseField() (filterField, error) {
+	t := p.peek()
+	if t.kind == tokDot {
+		return p.parsePath()
+	}
+	if t.kind != tokIdent {
+		return nil, fmt.Errorf("expected a field but found %s", t)
+	}
+	p.next()
+
+	switch strings.ToLower(t.text) {
+	case "key":
+		return keyField{}, nil
+	case "value":
+		return valueField{}, nil
+	case "topic":
+		return topicField{}, nil
+	case "partition":
+		return partitionField{}, nil
+	case "offset":
+		return offsetField{}, nil
+	case "timestamp":
+		return timestampField{}, nil
+	case "headers", "header":
+		switch next := p.next(); next.kind {
+		case tokDot:
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, fmt.Errorf("expected a header name but found %s", name)
+			}
+			return headerField{name.text}, nil
+		case tokLBracket:
+			name := p.next()
+			if name.kind != tokString {
+				return nil, fmt.Errorf("expected a quoted header name but found %s", name)
+			}
+			if closing := p.next(); closing.kind != tokRBracket {
+				return nil, fmt.Errorf("expected ] but found %s", closing)
+			}
+			return headerField{name.text}, nil
+		default:
+			return nil, fmt.Errorf(`expected headers.name or headers["name"] but found %s`, next)
+		}
+	default:
+		return nil, fmt.Errorf("unknown field %q (expected .path, key, value, topic, partition, offset, timestamp or headers.name)", t.text)
+	}
+}
+
+// parsePath parses .a.b[0]["c d"]; a lone . is the whole value
+func (p *filterParser) parsePath() (filterField, error) {
+	var path pathField
+	for {
+		switch p.peek().kind {
+		case tokDot:
+			p.next()
+			if p.peek().kind == tokIdent {
+				path = append(path, p.next().text)
+			}
+		case tokLBracket:
+			p.next()
+			t := p.next()
+			switch t.kind {
+			case tokNumber:
+				index, err := strconv.Atoi(t.text)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid array index %s", t)
+				}
+				path = append(path, index)
+			case tokString:
+				path = append(path, t.text)
+			default:
+				return nil, fmt.Errorf("expected an index or a quoted key but found %s", t)
+			}
+			if closing := p.next(); closing.kind != tokRBracket {
+				return nil, fmt.Errorf("expected ] but found %s", closing)
+			}
+		default:
+			return path, nil
+		}
+	}
+}
+
+func (p *filterParser) parseLiteral() (any, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return t.text, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", t)
+		}
+		return n, nil
+	case tokIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a string, number, true, false or null but found %s", t)
+}
+
+// Evaluation
+
+type filterNode interface {
+	eval(r *filterRecord) bool
+}
+
+type andNode struct{ left, right filterNode }
+
+func (n andNode) eval(r *filterRecord) bool { return n.left.eval(r) && n.right.eval(r) }
+
+type orNode struct{ left, right filterNode }
+
+func (n orNode) eval(r *filterRecord) bool { return n.left.eval(r) || n.right.eval(r) }
+
+type notNode struct{ inner filterNode }
+
+func (n notNode) eval(r *filterRecord) bool { return !n.inner.eval(r) }
+
+// truthyNode is true when the field is present and not false or null
+type truthyNode struct{ field filterField }
+
+func (n truthyNode) eval(r *filterRecord) bool {
+	v := n.field.value(r)
+	return v != nil && v != false
+}
+
+type compareNode struct {
+	field   filterField
+	op      string
+	literal any
+	re      *regexp.Regexp
+}
+
+func newCompareNode(field filterField, op string, literal any) (filterNode, error) {
+	n := compareNode{field: field, op: op, literal: literal}
+
+	switch op {
+	case "=~", "!~":
+		pattern, ok := literal.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s expects a quoted regular expression", op)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+		n.re = re
+		return n, nil
+	case "<", "<=", ">", ">=":
+		if literal == nil || literal == true || literal == false {
+			return nil, fmt.Errorf("%s expects a number or a string", op)
+		}
+	}
+
+	// Timestamps are compared as times, whatever the literal looks like
+	if _, ok := field.(timestampField); ok && literal != nil {
+		switch v := literal.(type) {
+		case string:
+			t, err := parseTimeWithTimezone(v)
+			if err != nil {
+				return nil, err
+			}
+			n.literal = t
+		case float64:
+			n.literal = time.UnixMilli(int64(v))
+		default:
+			return nil, fmt.Errorf("timestamp must be compared with a time or Unix milliseconds")
+		}
+	}
+	return n, nil
+}
+
+func (n compareNode) eval(r *filterRecord) bool {
+	left := n.field.value(r)
+	switch n.op {
+	case "==":
+		return filterEqual(left, n.literal)
+	case "!=":
+		return !filterEqual(left, n.literal)
+	case "=~", "!~":
+		s, ok := left.(string)
+		matched := ok && n.re.MatchString(s)
+		return matched == (n.op == "=~")
+	case "contains":
+		return filterContains(left, n.literal)
+	default:
+		cmp, ok := filterCompare(left, n.literal)
+		if !ok {
+			return false
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		default:
+			return cmp >= 0
+		}
+	}
+}
+
+func filterEqual(a, b any) bool {
+	if at, ok := a.(time.Time); ok {
+		bt, ok := b.(time.Time)
+		return ok && at.Equal(bt)
+	}
+	switch a.(type) {
+	case nil, float64, string, bool:
+		return a == b
+	}
+	// Objects and arrays are equal to nothing but themselves
+	return false
+}
+
+// filterCompare orders two numbers, strings or times
+func filterCompare(a, b any) (int, bool) {
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			switch {
+			case av < bv:
+				return -1, true
+			case av > bv:
+				return 1, true
+			}
+			return 0, true
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), true
+		}
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return av.Compare(bv), true
+		}
+	}
+	return 0, false
+}
+
+func filterContains(container, item any) bool {
+	switch c := container.(type) {
+	case string:
+		s, ok := item.(string)
+		return ok && strings.Contains(c, s)
+	case []any:
+		for _, element := range c {
+			if filterEqual(element, item) {
+				return true
+			}
+		}
+	case map[string]any:
+		if s, ok := item.(string); ok {
+			_, exists := c[s]
+			return exists
+		}
+	}
+	return false
+}
+
+// filterField extracts a value from a record: nil, float64, string, bool,
+// []any, map[string]any or time.Time
+type filterField interface {
+	value(r *filterRecord) any
+}
+
+type keyField struct{}
+
+func (keyField) value(r *filterRecord) any { return optionalString(r.record.Key) }
+
+type valueField struct{}
+
+func (valueField) value(r *filterRecord) any { return optionalString(r.record.Value) }
+
+type topicField struct{}
+
+func (topicField) value(r *filterRecord) any { return r.record.Topic }
+
+type partitionField struct{}
+
+func (partitionField) value(r *filterRecord) any { return float64(r.record.Partition) }
+
+type offsetField struct{}
+
+func (offsetField) value(r *filterRecord) any { return float64(r.record.Offset) }
+
+type timestampField struct{}
+
+func (timestampField) value(r *filterRecord) any { return r.record.Timestamp }
+
+// headerField is the last value of a header, or nil when the record doesn't have it
+type headerField struct{ name string }
+
+func (f headerField) value(r *filterRecord) any {
+	var v any
+	for _, h := range r.record.Headers {
+		if h.Key == f.name {
+			v = string(h.Value)
+		}
+	}
+	return v
+}
+
+// pathField walks the JSON value with object keys (string) and array indices (int)
+type pathField []any
+
+func (f pathField) value(r *filterRecord) any {
+	v := r.document()
+	for _, segment := range f {
+		switch s := segment.(type) {
+		case string:
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil
+			}
+			v = obj[s]
+		case int:
+			arr, ok := v.([]any)
+			if !ok || s >= len(arr) {
+				return nil
+			}
+			v = arr[s]
+		}
+	}
+	return v
+}
+
+func optionalString(b []byte) any {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
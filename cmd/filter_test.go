@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func filterRecordFixture() *kgo.Record {
+	return &kgo.Record{
+		Topic:     "orders",
+		Partition: 3,
+		Offset:    1500,
+		Timestamp: time.Date(2025, 10, 8, 15, 30, 0, 0, time.UTC),
+		Key:       []byte("order-42"),
+		Value:     []byte(`{"user":{"id":42,"name":"Ada"},"items":[{"sku":"A-1"},{"sku":"B-2"}],"tags":["vip"],"paid":true,"a b":1}`),
+		Headers: []kgo.RecordHeader{
+			{Key: "source", Value: []byte("web")},
+			{Key: "trace-id", Value: []byte("abc")},
+		},
+	}
+}
+
+func TestRecordFilterMatch(t *testing.T) {
+	record := filterRecordFixture()
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`.user.id == 42`, true},
+		{`.user.id != 42`, false},
+		{`.user.id > 40 and .user.id <= 42`, true},
+		{`.user.name == "Ada"`, true},
+		{`.user.name == 'Bob' or .user.name == "Ada"`, true},
+		{`.items[1].sku == "B-2"`, true},
+		{`.items[5].sku == null`, true},
+		{`.["a b"] == 1`, true},
+		{`.tags contains "vip"`, true},
+		{`.user contains "name"`, true},
+		{`.paid`, true},
+		{`not .paid`, false},
+		{`.missing`, false},
+		{`.missing == null`, true},
+		{`.missing > 1`, false},
+		{`headers.source == "web"`, true},
+		{`headers["trace-id"] == "abc" && headers.trace-id == "abc"`, true},
+		{`headers.absent`, false},
+		{`key =~ "^order-[0-9]+$"`, true},
+		{`key !~ '^order-'`, false},
+		{`value contains "Ada"`, true},
+		{`topic == "orders" and partition == 3`, true},
+		{`offset >= 1000 and offset < 2000`, true},
+		{`timestamp >= "2025-10-08T15:00:00Z" and timestamp < "2025-10-08T16:00:00Z"`, true},
+		{`timestamp > 1759930000000`, true},
+		{`(.user.id == 1 or .user.id == 42) and !(headers.source == "api")`, true},
+		{`.user.id == 1 or .user.id == 2 and .paid`, false},
+	}
+	for _, tt := range tests {
+		f, err := parseFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.expr, err)
+		}
+		if got := f.Match(record); got != tt.want {
+			t.Fatalf("%s: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestRecordFilterNonJSONValue(t *testing.T) {
+	record := filterRecordFixture()
+	record.Value = []byte("plain text")
+
+	f, err := parseFilter(`.user.id == 42 or value == "plain text"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !f.Match(record) {
+		t.Fatalf("expected the value comparison to match")
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		`.user.id ==`,
+		`.user.id == 42 and`,
+		`(.user.id == 42`,
+		`unknown == 1`,
+		`key =~ 42`,
+		`key =~ "("`,
+		`timestamp > "yesterday"`,
+		`.paid > true`,
+		`"unterminated`,
+		`.user.id == 42 extra`,
+		`.user.id # 42`,
+	} {
+		if _, err := parseFilter(expr); err == nil {
+			t.Fatalf("%s: expected error", expr)
+		}
+	}
+}
+
+func TestNilFilterMatchesEverything(t *testing.T) {
+	f, err := parseFilter("  ")
+	if err != nil || f != nil {
+		t.Fatalf("expected a nil filter, got %v %v", f, err)
+	}
+	if !f.Match(filterRecordFixture()) {
+		t.Fatalf("expected a nil filter to match")
+	}
+}
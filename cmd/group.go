@@ -0,0 +1,419 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kadm"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+var (
+	groupOutput      string
+	groupStateFilter []string
+)
+
+// groupSummary is a group as listed by group list
+type groupSummary struct {
+	Group        string
+	State        string
+	ProtocolType string
+	Coordinator  int32
+}
+
+// groupDescription is the describe output of a consumer group
+type groupDescription struct {
+	Group        string
+	State        string
+	ProtocolType string
+	Protocol     string
+	Coordinator  string
+	Members      []groupMember
+	Offsets      []partitionLag
+	TotalLag     int64
+}
+
+// groupMember is a member of a group and the partitions it was assigned
+type groupMember struct {
+	MemberID    string
+	InstanceID  string `json:",omitempty"`
+	ClientID    string
+	Host        string
+	Assignments map[string][]int32
+}
+
+// partitionLag is the committed offset and lag of a group on a partition.
+// CommittedOffset is -1 when the group never committed, Lag is -1 when it
+// could not be computed.
+type partitionLag struct {
+	Topic           string
+	Partition       int32
+	CommittedOffset int64
+	LogEndOffset    int64
+	Lag             int64
+	MemberID        string `json:",omitempty"`
+	ClientID        string `json:",omitempty"`
+	Host            string `json:",omitempty"`
+	Error           string `json:",omitempty"`
+}
+
+// groupLagReport is the lag output of a consumer group
+type groupLagReport struct {
+	Group      string
+	State      string
+	Topics     []topicLagTotal
+	Partitions []partitionLag
+	TotalLag   int64
+}
+
+// topicLagTotal is the total lag of a group on one topic
+type topicLagTotal struct {
+	Topic string
+	Lag   int64
+}
+
+// groupCmd represents the group command
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Inspect Kafka consumer groups",
+}
+
+var listGroupsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all consumer groups",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateGroupOutput(); err != nil {
+			return err
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		listed, err := adminClient.ListGroups(context.Background(), groupStateFilter...)
+		if err != nil {
+			return fmt.Errorf("failed to list groups: %w", err)
+		}
+
+		groups := make([]groupSummary, 0, len(listed))
+		for _, g := range listed.Sorted() {
+			groups = append(groups, groupSummary{
+				Group:        g.Group,
+				State:        g.State,
+				ProtocolType: g.ProtocolType,
+				Coordinator:  g.Coordinator,
+			})
+		}
+
+		if groupOutput == OutputJSON {
+			return writeJSON(groups)
+		}
+
+		if len(groups) == 0 {
+			color.Yellow("No consumer groups found")
+			return nil
+		}
+		color.Blue("Consumer groups:")
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "GROUP\tSTATE\tTYPE\tCOORDINATOR")
+		for _, g := range groups {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", g.Group, g.State, g.ProtocolType, g.Coordinator)
+		}
+		return tw.Flush()
+	},
+}
+
+var describeGroupCmd = &cobra.Command{
+	Use:   "describe <group>",
+	Short: "Describe a consumer group's members, offsets and lag",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateGroupOutput(); err != nil {
+			return err
+		}
+
+		described, lag, err := loadGroupLag(args[0])
+		if err != nil {
+			return err
+		}
+
+		desc := buildGroupDescription(described, lag)
+		if groupOutput == OutputJSON {
+			return writeJSON(desc)
+		}
+		printGroupDescription(desc)
+		return nil
+	},
+}
+
+var lagCmd = &cobra.Command{
+	Use:   "lag <group>",
+	Short: "Show a consumer group's lag per partition and topic",
+	Long: `Show the committed offset, log-end offset and lag of a consumer group on every
+partition it is assigned or has committed offsets for, with per-topic and total lag.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateGroupOutput(); err != nil {
+			return err
+		}
+
+		described, lag, err := loadGroupLag(args[0])
+		if err != nil {
+			return err
+		}
+
+		report := buildGroupLagReport(described, lag)
+		if groupOutput == OutputJSON {
+			return writeJSON(report)
+		}
+		printGroupLagReport(report)
+		return nil
+	},
+}
+
+func validateGroupOutput() error {
+	if groupOutput != OutputTable && groupOutput != OutputJSON {
+		return fmt.Errorf("unsupported --output %q (expected %s or %s)", groupOutput, OutputTable, OutputJSON)
+	}
+	return nil
+}
+
+// loadGroupLag describes group and computes its lag
+func loadGroupLag(group string) (kadm.DescribedGroup, kadm.GroupLag, error) {
+	cfg := kafka.LoadConfig()
+	client, adminClient, err := cfg.NewAdminClient()
+	if err != nil {
+		return kadm.DescribedGroup{}, nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	described, err := adminClient.DescribeGroup(ctx, group)
+	if err != nil {
+		return described, nil, fmt.Errorf("failed to describe group %s: %w", group, err)
+	}
+	if described.State == "Dead" {
+		return described, nil, fmt.Errorf("group %s does not exist", group)
+	}
+
+	lag, err := adminClient.GroupLag(ctx, described)
+	if err != nil {
+		return described, nil, err
+	}
+	return described, lag, nil
+}
+
+// buildGroupDescription combines a described group with its lag
+func buildGroupDescription(described kadm.DescribedGroup, lag kadm.GroupLag) groupDescription {
+	desc := groupDescription{
+		Group:        described.Group,
+		State:        described.State,
+		ProtocolType: described.ProtocolType,
+		Protocol:     described.Protocol,
+		Members:      []groupMember{},
+	}
+	if described.Coordinator.Host != "" {
+		desc.Coordinator = fmt.Sprintf("%d (%s:%d)", described.Coordinator.NodeID, described.Coordinator.Host, described.Coordinator.Port)
+	}
+
+	for _, m := range described.Members {
+		member := groupMember{
+			MemberID:    m.MemberID,
+			ClientID:    m.ClientID,
+			Host:        m.ClientHost,
+			Assignments: map[string][]int32{},
+		}
+		if m.InstanceID != nil {
+			member.InstanceID = *m.InstanceID
+		}
+		if assigned, ok := m.Assigned.AsConsumer(); ok {
+			for _, t := range assigned.Topics {
+				partitions := append([]int32(nil), t.Partitions...)
+				sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+				member.Assignments[t.Topic] = partitions
+			}
+		}
+		desc.Members = append(desc.Members, member)
+	}
+
+	desc.Offsets, desc.TotalLag = buildPartitionLags(lag)
+	return desc
+}
+
+// buildGroupLagReport summarizes the lag of a group per partition and per topic
+func buildGroupLagReport(described kadm.DescribedGroup, lag kadm.GroupLag) groupLagReport {
+	report := groupLagReport{
+		Group:  described.Group,
+		State:  described.State,
+		Topics: []topicLagTotal{},
+	}
+	report.Partitions, report.TotalLag = buildPartitionLags(lag)
+
+	for _, t := range lag.TotalByTopic().Sorted() {
+		report.Topics = append(report.Topics, topicLagTotal{Topic: t.Topic, Lag: t.Lag})
+	}
+	return report
+}
+
+// buildPartitionLags flattens a group lag into sorted rows and returns the total lag
+func buildPartitionLags(lag kadm.GroupLag) ([]partitionLag, int64) {
+	rows := []partitionLag{}
+	var total int64
+	for _, l := range lag.Sorted() {
+		row := partitionLag{
+			Topic:           l.Topic,
+			Partition:       l.Partition,
+			CommittedOffset: l.Commit.At,
+			LogEndOffset:    l.End.Offset,
+			Lag:             l.Lag,
+		}
+		if l.End.Err != nil {
+			row.LogEndOffset = -1
+		}
+		if !l.IsEmpty() {
+			row.MemberID = l.Member.MemberID
+			row.ClientID = l.Member.ClientID
+			row.Host = l.Member.ClientHost
+		}
+		if l.Err != nil {
+			row.Error = l.Err.Error()
+		}
+		if l.Lag > 0 {
+			total += l.Lag
+		}
+		rows = append(rows, row)
+	}
+	return rows, total
+}
+
+func printGroupDescription(desc groupDescription) {
+	color.Cyan("👥 Group: %s", desc.Group)
+	fmt.Printf("State: %s\n", desc.State)
+	if desc.Protocol != "" {
+		fmt.Printf("Protocol: %s (%s)\n", desc.Protocol, desc.ProtocolType)
+	}
+	if desc.Coordinator != "" {
+		fmt.Printf("Coordinator: %s\n", desc.Coordinator)
+	}
+
+	fmt.Println()
+	if len(desc.Members) == 0 {
+		color.Blue("Members: none")
+	} else {
+		color.Blue("Members:")
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "MEMBER ID\tCLIENT ID\tHOST\tASSIGNMENTS")
+		for _, m := range desc.Members {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", m.MemberID, m.ClientID, m.Host, formatAssignments(m.Assignments))
+		}
+		tw.Flush()
+	}
+
+	fmt.Println()
+	printPartitionLags(desc.Offsets)
+	color.Cyan("📊 Total lag: %d", desc.TotalLag)
+}
+
+func printGroupLagReport(report groupLagReport) {
+	color.Cyan("👥 Group: %s (%s)", report.Group, report.State)
+	fmt.Println()
+	printPartitionLags(report.Partitions)
+
+	if len(report.Topics) > 1 {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "TOPIC\tLAG")
+		for _, t := range report.Topics {
+			fmt.Fprintf(tw, "%s\t%d\n", t.Topic, t.Lag)
+		}
+		tw.Flush()
+		fmt.Println()
+	}
+	color.Cyan("📊 Total lag: %d", report.TotalLag)
+}
+
+func printPartitionLags(rows []partitionLag) {
+	if len(rows) == 0 {
+		color.Blue("Offsets: no committed offsets or assignments")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOPIC\tPARTITION\tCOMMITTED\tLOG-END\tLAG\tCLIENT ID\tHOST\t")
+	for _, r := range rows {
+		lag := fmt.Sprint(r.Lag)
+		if r.Lag < 0 {
+			lag = "?"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Topic, r.Partition, committedName(r.CommittedOffset), offsetName(r.LogEndOffset),
+			lag, dashIfEmpty(r.ClientID), dashIfEmpty(r.Host), lagWarning(r))
+	}
+	tw.Flush()
+	fmt.Println()
+}
+
+func lagWarning(r partitionLag) string {
+	if r.Error == "" {
+		return ""
+	}
+	return color.RedString("⚠️  %s", r.Error)
+}
+
+func committedName(offset int64) string {
+	if offset < 0 {
+		return "-"
+	}
+	return fmt.Sprint(offset)
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// formatAssignments renders assignments as "topic[0,1] other[2]"
+func formatAssignments(assignments map[string][]int32) string {
+	if len(assignments) == 0 {
+		return "-"
+	}
+	topics := make([]string, 0, len(assignments))
+	for t := range assignments {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+
+	parts := make([]string, len(topics))
+	for i, t := range topics {
+		parts[i] = fmt.Sprintf("%s[%s]", t, joinInt32s(assignments[t]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeJSON prints v as indented JSON on stdout
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func init() {
+	rootCmd.AddCommand(groupCmd)
+	groupCmd.AddCommand(listGroupsCmd)
+	groupCmd.AddCommand(describeGroupCmd)
+	groupCmd.AddCommand(lagCmd)
+
+	groupCmd.PersistentFlags().StringVarP(&groupOutput, "output", "o", OutputTable, "Output format: table or json")
+	listGroupsCmd.Flags().StringSliceVar(&groupStateFilter, "state", nil, "Only list groups in these states (e.g. Stable,Empty)")
+}
@@ -0,0 +1,455 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kadm"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+var (
+	resetTopics     []string
+	resetAllTopics  bool
+	resetToEarliest bool
+	resetToLatest   bool
+	resetToDatetime string
+	resetToOffset   int64
+	resetShiftBy    int64
+	resetFromFile   string
+	resetExecute    bool
+)
+
+// topicPartition identifies a single partition of a topic
+type topicPartition struct {
+	Topic     string
+	Partition int32
+}
+
+// offsetReset is the planned change of a group's committed offset on one partition.
+// Current is -1 when the group never committed; skipped partitions are not committed.
+type offsetReset struct {
+	Topic     string
+	Partition int32
+	Current   int64
+	Target    int64
+	Skipped   string `json:",omitempty"`
+}
+
+// resetBounds holds what is needed to plan resets: the group's commits and
+// the earliest and latest offsets of every partition in scope
+type resetBounds struct {
+	Committed kadm.OffsetResponses
+	Start     kadm.ListedOffsets
+	End       kadm.ListedOffsets
+}
+
+// resetTargetFunc returns the wanted offset of a partition before clamping.
+// current is -1 when nothing is committed.
+type resetTargetFunc func(tp topicPartition, current, start, end int64) (int64, error)
+
+var resetOffsetsCmd = &cobra.Command{
+	Use:   "reset-offsets <group>",
+	Short: "Reset the committed offsets of a consumer group",
+	Long: `Reset the committed offsets of a consumer group on the partitions in scope.
+
+Choose exactly one strategy: --to-earliest, --to-latest, --to-datetime, --to-offset,
+--shift-by or --from-file. Scope the reset with --topic (repeatable, "topic" or
+"topic:0,1,2") or --all-topics (every topic the group committed to). --from-file
+reads "topic,partition,offset" lines and is its own scope.
+
+New offsets are clamped to the earliest and latest offsets of each partition.
+By default only the plan is printed; nothing is committed without --execute.
+The group must have no active members.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		group := args[0]
+		if err := validateGroupOutput(); err != nil {
+			return err
+		}
+
+		strategy, err := resetStrategyName(cmd)
+		if err != nil {
+			return err
+		}
+		if strategy == "from-file" && (len(resetTopics) > 0 || resetAllTopics) {
+			return fmt.Errorf("--from-file can't be combined with --topic or --all-topics")
+		}
+		if strategy != "from-file" && (len(resetTopics) > 0) == resetAllTopics {
+			return fmt.Errorf("exactly one of --topic or --all-topics is required")
+		}
+
+		var fileOffsets map[topicPartition]int64
+		if strategy == "from-file" {
+			if fileOffsets, err = readResetFile(resetFromFile); err != nil {
+				return err
+			}
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		described, err := adminClient.DescribeGroup(ctx, group)
+		if err != nil {
+			return fmt.Errorf("failed to describe group %s: %w", group, err)
+		}
+		if len(described.Members) > 0 {
+			return fmt.Errorf("group %s has %d active members (state %s); stop its consumers before resetting offsets",
+				group, len(described.Members), described.State)
+		}
+
+		committed, err := adminClient.FetchOffsets(ctx, group)
+		if err != nil {
+			return fmt.Errorf("failed to fetch committed offsets: %w", err)
+		}
+
+		var scope []topicPartition
+		switch {
+		case fileOffsets != nil:
+			for tp := range fileOffsets {
+				scope = append(scope, tp)
+			}
+			err = checkPartitionsExist(ctx, adminClient, scope)
+		case resetAllTopics:
+			scope, err = resolveResetScope(ctx, adminClient, committed.Partitions().Topics())
+		default:
+			scope, err = resolveResetScope(ctx, adminClient, resetTopics)
+		}
+		if err != nil {
+			return err
+		}
+		if len(scope) == 0 {
+			return fmt.Errorf("no partitions in scope")
+		}
+		sortTopicPartitions(scope)
+
+		topics := topicsOf(scope)
+		bounds := resetBounds{Committed: committed}
+		if bounds.Start, err = adminClient.ListStartOffsets(ctx, topics...); err != nil {
+			return fmt.Errorf("failed to get earliest offsets: %w", err)
+		}
+		if bounds.End, err = adminClient.ListEndOffsets(ctx, topics...); err != nil {
+			return fmt.Errorf("failed to get latest offsets: %w", err)
+		}
+
+		var target resetTargetFunc
+		switch strategy {
+		case "to-earliest":
+			target = func(_ topicPartition, _, start, _ int64) (int64, error) { return start, nil }
+		case "to-latest":
+			target = func(_ topicPartition, _, _, end int64) (int64, error) { return end, nil }
+		case "to-offset":
+			target = func(topicPartition, int64, int64, int64) (int64, error) { return resetToOffset, nil }
+		case "shift-by":
+			target = func(_ topicPartition, current, _, _ int64) (int64, error) {
+				if current < 0 {
+					return 0, fmt.Errorf("no committed offset to shift")
+				}
+				return current + resetShiftBy, nil
+			}
+		case "from-file":
+			target = func(tp topicPartition, _, _, _ int64) (int64, error) { return fileOffsets[tp], nil }
+		case "to-datetime":
+			at, err := parseTimeWithTimezone(resetToDatetime)
+			if err != nil {
+				return fmt.Errorf("invalid --to-datetime: %w", err)
+			}
+			atOffsets, err := adminClient.ListOffsetsAfterMilli(ctx, at.UnixMilli(), topics...)
+			if err != nil {
+				return fmt.Errorf("failed to get offsets for %s: %w", resetToDatetime, err)
+			}
+			target = func(tp topicPartition, _, _, end int64) (int64, error) {
+				// No record at or after the time: start from the end
+				if offset, ok := lookupOffset(atOffsets, tp.Topic, tp.Partition); ok && offset >= 0 {
+					return offset, nil
+				}
+				return end, nil
+			}
+		}
+
+		plan := planOffsetResets(scope, bounds, target)
+		if groupOutput == OutputJSON {
+			if err := writeJSON(plan); err != nil {
+				return err
+			}
+		} else {
+			color.Cyan("👥 Offset reset plan for group '%s' (%s)", group, strategy)
+			printOffsetResetPlan(plan)
+		}
+
+		if !resetExecute {
+			if groupOutput == OutputTable {
+				color.Yellow("⚠️  Dry run: nothing committed. Re-run with --execute to apply this plan")
+			}
+			return nil
+		}
+
+		offsets := make(kadm.Offsets)
+		for _, r := range plan {
+			if r.Skipped == "" {
+				offsets.AddOffset(r.Topic, r.Partition, r.Target, -1)
+			}
+		}
+		if len(offsets) == 0 {
+			return fmt.Errorf("nothing to commit, every partition was skipped")
+		}
+
+		resps, err := adminClient.CommitOffsets(ctx, group, offsets)
+		if err != nil {
+			return fmt.Errorf("failed to commit offsets: %w", err)
+		}
+		var failed int
+		resps.EachError(func(o kadm.OffsetResponse) {
+			color.Red("❌ %s[%d]: %v", o.Topic, o.Partition, o.Err)
+			failed++
+		})
+		if failed > 0 {
+			return fmt.Errorf("failed to commit %d of %d offsets", failed, len(offsets.Sorted()))
+		}
+		if groupOutput == OutputTable {
+			color.Green("✅ Committed %d offsets for group '%s'", len(offsets.Sorted()), group)
+		}
+		return nil
+	},
+}
+
+// resetStrategyName returns the single reset strategy selected on the command line
+func resetStrategyName(cmd *cobra.Command) (string, error) {
+	var selected []string
+	for _, name := range []string{"to-earliest", "to-latest", "to-datetime", "to-offset", "shift-by", "from-file"} {
+		if cmd.Flags().Changed(name) {
+			selected = append(selected, name)
+		}
+	}
+	if len(selected) != 1 {
+		return "", fmt.Errorf("exactly one of --to-earliest, --to-latest, --to-datetime, --to-offset, --shift-by or --from-file is required")
+	}
+	return selected[0], nil
+}
+
+// planOffsetResets computes the new offset of every partition in scope, clamped
+// to the partition's earliest and latest offsets
+func planOffsetResets(scope []topicPartition, bounds resetBounds, target resetTargetFunc) []offsetReset {
+	plan := make([]offsetReset, 0, len(scope))
+	for _, tp := range scope {
+		r := offsetReset{Topic: tp.Topic, Partition: tp.Partition, Current: -1, Target: -1}
+		if c, ok := bounds.Committed.Lookup(tp.Topic, tp.Partition); ok && c.Err == nil {
+			r.Current = c.At
+		}
+
+		start, startOK := lookupOffset(bounds.Start, tp.Topic, tp.Partition)
+		end, endOK := lookupOffset(bounds.End, tp.Topic, tp.Partition)
+		if !startOK || !endOK {
+			r.Skipped = "offsets unavailable"
+			plan = append(plan, r)
+			continue
+		}
+
+		offset, err := target(tp, r.Current, start, end)
+		if err != nil {
+			r.Skipped = err.Error()
+			plan = append(plan, r)
+			continue
+		}
+		r.Target = max(start, min(offset, end))
+		plan = append(plan, r)
+	}
+	return plan
+}
+
+func printOffsetResetPlan(plan []offsetReset) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOPIC\tPARTITION\tCURRENT\t\tNEW\tCHANGE\t")
+	for _, r := range plan {
+		if r.Skipped != "" {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t\t-\t-\t%s\n", r.Topic, r.Partition, committedName(r.Current),
+				color.YellowString("skipped: %s", r.Skipped))
+			continue
+		}
+		change := "-"
+		if r.Current >= 0 {
+			change = fmt.Sprintf("%+d", r.Target-r.Current)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t→\t%d\t%s\t\n", r.Topic, r.Partition, committedName(r.Current), r.Target, change)
+	}
+	tw.Flush()
+}
+
+// resolveResetScope expands "topic" and "topic:0,1" specs into partitions,
+// checking that the topics and partitions exist
+func resolveResetScope(ctx context.Context, adminClient *kafka.AdminClient, specs []string) ([]topicPartition, error) {
+	requested := make(map[string][]int32)
+	var topics []string
+	for _, spec := range specs {
+		topic, partitions, err := parseTopicPartitions(spec)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := requested[topic]; !ok {
+			topics = append(topics, topic)
+		}
+		requested[topic] = append(requested[topic], partitions...)
+	}
+	if len(topics) == 0 {
+		return nil, nil
+	}
+
+	details, err := adminClient.ListTopics(ctx, topics...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topic details: %w", err)
+	}
+
+	var scope []topicPartition
+	seen := make(map[topicPartition]bool)
+	for _, topic := range topics {
+		detail, ok := details[topic]
+		if !ok || detail.Err != nil {
+			return nil, fmt.Errorf("topic %s does not exist", topic)
+		}
+		partitions := requested[topic]
+		if len(partitions) == 0 {
+			partitions = detail.Partitions.Numbers()
+		}
+		for _, p := range partitions {
+			if _, ok := detail.Partitions[p]; !ok {
+				return nil, fmt.Errorf("topic %s has no partition %d", topic, p)
+			}
+			tp := topicPartition{Topic: topic, Partition: p}
+			if !seen[tp] {
+				seen[tp] = true
+				scope = append(scope, tp)
+			}
+		}
+	}
+	return scope, nil
+}
+
+// checkPartitionsExist fails when any partition is missing from the cluster
+func checkPartitionsExist(ctx context.Context, adminClient *kafka.AdminClient, scope []topicPartition) error {
+	details, err := adminClient.ListTopics(ctx, topicsOf(scope)...)
+	if err != nil {
+		return fmt.Errorf("failed to get topic details: %w", err)
+	}
+	for _, tp := range scope {
+		detail, ok := details[tp.Topic]
+		if !ok || detail.Err != nil {
+			return fmt.Errorf("topic %s does not exist", tp.Topic)
+		}
+		if _, ok := detail.Partitions[tp.Partition]; !ok {
+			return fmt.Errorf("topic %s has no partition %d", tp.Topic, tp.Partition)
+		}
+	}
+	return nil
+}
+
+// parseTopicPartitions parses "topic" or "topic:0,1,2"
+func parseTopicPartitions(spec string) (string, []int32, error) {
+	topic, list, hasPartitions := strings.Cut(spec, ":")
+	if topic == "" {
+		return "", nil, fmt.Errorf("invalid topic %q", spec)
+	}
+	if !hasPartitions {
+		return topic, nil, nil
+	}
+
+	var partitions []int32
+	for _, s := range strings.Split(list, ",") {
+		p, err := strconv.ParseInt(strings.TrimSpace(s), 10, 32)
+		if err != nil || p < 0 {
+			return "", nil, fmt.Errorf("invalid partition %q in %q", s, spec)
+		}
+		partitions = append(partitions, int32(p))
+	}
+	return topic, partitions, nil
+}
+
+// readResetFile reads "topic,partition,offset" lines. Empty lines and lines
+// starting with # are ignored.
+func readResetFile(path string) (map[topicPartition]int64, error) {
+	in, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	offsets := make(map[topicPartition]int64)
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ",")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: expected topic,partition,offset", path, line)
+		}
+		topic := strings.TrimSpace(fields[0])
+		partition, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 32)
+		if err != nil || partition < 0 || topic == "" {
+			return nil, fmt.Errorf("%s:%d: invalid topic or partition", path, line)
+		}
+		offset, err := strconv.ParseInt(strings.TrimSpace(fields[2]), 10, 64)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("%s:%d: invalid offset %q", path, line, fields[2])
+		}
+		offsets[topicPartition{Topic: topic, Partition: int32(partition)}] = offset
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("%s contains no offsets", path)
+	}
+	return offsets, nil
+}
+
+func sortTopicPartitions(tps []topicPartition) {
+	sort.Slice(tps, func(i, j int) bool {
+		if tps[i].Topic != tps[j].Topic {
+			return tps[i].Topic < tps[j].Topic
+		}
+		return tps[i].Partition < tps[j].Partition
+	})
+}
+
+// topicsOf returns the distinct topics of tps
+func topicsOf(tps []topicPartition) []string {
+	seen := make(map[string]bool)
+	var topics []string
+	for _, tp := range tps {
+		if !seen[tp.Topic] {
+			seen[tp.Topic] = true
+			topics = append(topics, tp.Topic)
+		}
+	}
+	return topics
+}
+
+func init() {
+	groupCmd.AddCommand(resetOffsetsCmd)
+
+	resetOffsetsCmd.Flags().StringArrayVarP(&resetTopics, "topic", "t", nil, `Topic to reset, as "topic" or "topic:0,1,2" (repeatable)`)
+	resetOffsetsCmd.Flags().BoolVar(&resetAllTopics, "all-topics", false, "Reset every topic the group has committed offsets for")
+	resetOffsetsCmd.Flags().BoolVar(&resetToEarliest, "to-earliest", false, "Reset to the earliest available offset")
+	resetOffsetsCmd.Flags().BoolVar(&resetToLatest, "to-latest", false, "Reset to the latest offset, skipping every pending message")
+	resetOffsetsCmd.Flags().StringVar(&resetToDatetime, "to-datetime", "", "Reset to the first message at or after this time (same formats as extract)")
+	resetOffsetsCmd.Flags().Int64Var(&resetToOffset, "to-offset", 0, "Reset to this offset")
+	resetOffsetsCmd.Flags().Int64Var(&resetShiftBy, "shift-by", 0, "Move the committed offset by N (negative to replay)")
+	resetOffsetsCmd.Flags().StringVar(&resetFromFile, "from-file", "", `Reset to the offsets listed in a "topic,partition,offset" file ("-" for stdin)`)
+	resetOffsetsCmd.Flags().BoolVar(&resetExecute, "execute", false, "Commit the new offsets instead of only printing the plan")
+}
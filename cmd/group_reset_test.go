@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestPlanOffsetResets(t *testing.T) {
+	scope := []topicPartition{{"orders", 0}, {"orders", 1}, {"orders", 2}}
+	bounds := resetBounds{
+		Committed: kadm.OffsetResponses{"orders": {
+			0: {Offset: kadm.Offset{Topic: "orders", Partition: 0, At: 50}},
+			1: {Offset: kadm.Offset{Topic: "orders", Partition: 1, At: 5}},
+		}},
+		Start: kadm.ListedOffsets{"orders": {
+			0: {Offset: 10}, 1: {Offset: 0}, 2: {Offset: 0},
+		}},
+		End: kadm.ListedOffsets{"orders": {
+			0: {Offset: 100}, 1: {Offset: 20}, 2: {Offset: 30},
+		}},
+	}
+
+	shift := func(_ topicPartition, current, _, _ int64) (int64, error) {
+		if current < 0 {
+			return 0, errors.New("no committed offset to shift")
+		}
+		return current - 45, nil
+	}
+	plan := planOffsetResets(scope, bounds, shift)
+
+	if len(plan) != 3 {
+		t.Fatalf("expected 3 planned resets, got %d", len(plan))
+	}
+	if r := plan[0]; r.Current != 50 || r.Target != 10 {
+		t.Fatalf("expected 50 → 10 (clamped to earliest), got %+v", r)
+	}
+	if r := plan[1]; r.Current != 5 || r.Target != 0 {
+		t.Fatalf("expected 5 → 0 (clamped to earliest), got %+v", r)
+	}
+	if r := plan[2]; r.Current != -1 || r.Skipped == "" {
+		t.Fatalf("expected uncommitted partition to be skipped, got %+v", r)
+	}
+
+	toOffset := func(topicPartition, int64, int64, int64) (int64, error) { return 1000, nil }
+	plan = planOffsetResets(scope[:1], bounds, toOffset)
+	if plan[0].Target != 100 {
+		t.Fatalf("expected target clamped to latest offset 100, got %d", plan[0].Target)
+	}
+
+	plan = planOffsetResets([]topicPartition{{"missing", 0}}, bounds, toOffset)
+	if plan[0].Skipped != "offsets unavailable" {
+		t.Fatalf("expected partition without offsets to be skipped, got %+v", plan[0])
+	}
+}
+
+func TestParseTopicPartitions(t *testing.T) {
+	topic, partitions, err := parseTopicPartitions("orders:0, 2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if topic != "orders" || len(partitions) != 2 || partitions[0] != 0 || partitions[1] != 2 {
+		t.Fatalf("unexpected result: %s %v", topic, partitions)
+	}
+
+	topic, partitions, err = parseTopicPartitions("orders")
+	if err != nil || topic != "orders" || partitions != nil {
+		t.Fatalf("expected whole topic, got %s %v %v", topic, partitions, err)
+	}
+
+	for _, bad := range []string{":0", "orders:", "orders:a", "orders:-1"} {
+		if _, _, err := parseTopicPartitions(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestReadResetFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offsets.csv")
+	content := "# topic,partition,offset\norders,0,42\n\norders, 1, 7\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	offsets, err := readResetFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(offsets) != 2 || offsets[topicPartition{"orders", 0}] != 42 || offsets[topicPartition{"orders", 1}] != 7 {
+		t.Fatalf("unexpected offsets: %v", offsets)
+	}
+
+	if err := os.WriteFile(path, []byte("orders,0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readResetFile(path); err == nil {
+		t.Fatalf("expected error for a line without offset")
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestBuildPartitionLags(t *testing.T) {
+	member := &kadm.DescribedGroupMember{MemberID: "m-1", ClientID: "svc", ClientHost: "/10.0.0.1"}
+	lag := kadm.GroupLag{
+		"orders": {
+			1: {Member: member, Topic: "orders", Partition: 1, Commit: kadm.Offset{At: -1}, End: kadm.ListedOffset{Offset: 40}, Lag: 40},
+			0: {Member: member, Topic: "orders", Partition: 0, Commit: kadm.Offset{At: 90}, End: kadm.ListedOffset{Offset: 100}, Lag: 10},
+		},
+		"audit": {
+			0: {Topic: "audit", Partition: 0, Commit: kadm.Offset{At: 5}, End: kadm.ListedOffset{Err: errors.New("missing")}, Lag: -1, Err: errors.New("missing")},
+		},
+	}
+
+	rows, total := buildPartitionLags(lag)
+	if total != 50 {
+		t.Fatalf("expected total lag 50, got %d", total)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	if r := rows[0]; r.Topic != "audit" || r.LogEndOffset != -1 || r.Error != "missing" || r.ClientID != "" {
+		t.Fatalf("unexpected unassigned row: %+v", r)
+	}
+	if r := rows[1]; r.Topic != "orders" || r.Partition != 0 || r.CommittedOffset != 90 || r.Lag != 10 || r.ClientID != "svc" {
+		t.Fatalf("unexpected row: %+v", r)
+	}
+	if r := rows[2]; r.Partition != 1 || r.CommittedOffset != -1 || r.Lag != 40 {
+		t.Fatalf("unexpected uncommitted row: %+v", r)
+	}
+}
+
+func TestFormatAssignments(t *testing.T) {
+	got := formatAssignments(map[string][]int32{"orders": {0, 2}, "audit": {1}})
+	if got != "audit[1] orders[0,2]" {
+		t.Fatalf("expected %q, got %q", "audit[1] orders[0,2]", got)
+	}
+	if got := formatAssignments(nil); got != "-" {
+		t.Fatalf("expected -, got %q", got)
+	}
+}
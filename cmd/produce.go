@@ -2,10 +2,11 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
-	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -14,60 +15,132 @@ import (
 	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
 )
 
-type MessageEnvelope struct {
-	Topic   string                 `json:"Topic"`
-	Headers map[string]string      `json:"Headers,omitempty"`
-	Message map[string]interface{} `json:"Message"`
-}
-
 var (
-	message   string
-	inputFile string
+	message          string
+	inputFile        string
+	produceKey       string
+	producePartition int32
+	produceTimestamp string
+	produceHeaders   []string
+	partitionerName  string
+	maxInFlight      int
+	lineMode         string
+	keySubject       string
+	keyVersion       string
+	keyMessageType   string
+	valueSubject     string
+	valueVersion     string
+	valueMessageType string
 )
 
 // produceCmd represents the produce command
 var produceCmd = &cobra.Command{
 	Use:   "produce",
 	Short: "Produce a message to a Kafka topic",
-	Long: `Produce messages directly or from a JSON file.
-If a JSON file is provided with -i, each element should contain a "topic" and an "object" field:
+	Long: `Produce messages directly, from a JSON file or from stdin (-i -).
+If a JSON file is provided with -i, each element should contain a "Topic" and a "Message" field:
 [
-  { "topic": "topicA", "object": {...} },
-  { "topic": "topicB", "object": {...} }
-]`,
+  { "Topic": "topicA", "Message": {...} },
+  { "Topic": "topicB", "Message": {...} }
+]
+Message may be any JSON value. Non-JSON payloads can be given with ValueString (text),
+ValueBase64 (binary) or ValueFile (path to a file whose raw bytes become the value).
+The input may also be newline-delimited JSON (one envelope per line) and is read as a stream.
+With --line-mode raw, every line of the input becomes the value of a record sent to the topic argument.
+In envelope mode, the topic argument is used for envelopes without a Topic.
+Files written by extract can be replayed as is: Key, Partition, Timestamp and Headers are honored,
+and Value, Key and header values may be base64 or hex encoded (see ValueEncoding, KeyEncoding and
+HeaderEncoding).
+
+--key, --partition, --timestamp and --header set the record metadata of a --message. With -i they
+act as defaults for messages that don't set Key, Partition or Timestamp, and headers are added to
+every message.
+
+With --value-subject (and --key-subject), the JSON value (and key) of every message is encoded with
+the latest schema of the subject, or the version given by --value-version (--key-version), in the
+Schema Registry wire format. Avro, Protobuf and JSON Schema are supported. Protobuf data is
+encoded as the first message of the schema, or the message named by --value-message (--key-message).
+Envelopes written by extract with a ValueSchemaID or KeySchemaID are encoded again with that schema,
+as the message of ValueMessageType or KeyMessageType. This needs SCHEMA_REGISTRY_URL.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		color.Cyan("🚀 Kafka Producer")
 
 		if message == "" && inputFile == "" {
 			return fmt.Errorf("either --message or --input must be provided")
 		}
+		defaults, err := produceFlagsEnvelope(cmd)
+		if err != nil {
+			return err
+		}
+
+		var opts []kafka.ProducerOption
+		if cmd.Flags().Changed("partitioner") {
+			partitioner, err := kafka.ParsePartitioner(partitionerName)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, kafka.WithPartitioner(partitioner))
+		}
+
+		ctx := context.Background()
+		encoder, err := newRecordEncoder(ctx, kafka.LoadConfig(), keySubject, keyVersion, keyMessageType, valueSubject, valueVersion, valueMessageType)
+		if err != nil {
+			return err
+		}
+		if encoder.value != nil {
+			color.Blue("🧬 Encoding values with %s version %d (schema %d)", encoder.value.Subject, encoder.value.Version, encoder.value.ID)
+		}
+		if encoder.key != nil {
+			color.Blue("🧬 Encoding keys with %s version %d (schema %d)", encoder.key.Subject, encoder.key.Version, encoder.key.ID)
+		}
+
 		if inputFile != "" {
-			messages, err := HandleFileInput(inputFile)
+			if maxInFlight < 1 {
+				return fmt.Errorf("--max-in-flight must be at least 1")
+			}
+			if len(args) > 0 {
+				defaults.Topic = args[0]
+			}
+
+			in, err := openInput(inputFile)
 			if err != nil {
 				return fmt.Errorf("failed to handle file input: %v", err)
 			}
-			for i, msg := range messages {
-				jsonMsg, err := json.Marshal(msg.Message)
-				if err != nil {
-					return fmt.Errorf("failed to marshal message object: %v", err)
-				}
-				err = ProduceMessage(msg.Topic, string(jsonMsg), msg.Headers)
-				if err != nil {
-					return fmt.Errorf("failed to produce message: %v", err)
-				}
-				color.Green("✅ Produced message #%d to topic '%s'", i+1, msg.Topic)
+			defer in.Close()
+
+			reader, err := newMessageReader(in, lineMode, defaults.Topic)
+			if err != nil {
+				return err
+			}
+
+			if inputFile == "-" {
+				color.Blue("📥 Reading messages from stdin")
+			} else {
+				color.Blue("📂 Reading file: %s", inputFile)
+			}
+			if err := produceBatch(reader, defaults, encoder, append(opts, kafka.WithMaxBufferedRecords(maxInFlight))...); err != nil {
+				return err
 			}
 			color.Magenta("🎉 Done!")
 		} else {
 			if len(args) < 1 {
 				return fmt.Errorf("topic argument is required when using --message")
 			}
-			topic := args[0]
-			err := ProduceMessage(topic, message, nil)
+			env := defaults
+			env.Topic = args[0]
+			env.Value = &message
+			record, err := env.Record()
+			if err != nil {
+				return err
+			}
+			if err := encoder.Encode(ctx, env, record); err != nil {
+				return err
+			}
+			err = ProduceRecord(record, opts...)
 			if err != nil {
 				return fmt.Errorf("failed to produce message: %v", err)
 			}
-			color.Green("✅ Produced message to topic '%s'", topic)
+			color.Green("✅ Produced message to topic '%s'", env.Topic)
 			color.Magenta("🎉 Done!")
 		}
 		return nil
@@ -77,87 +150,161 @@ If a JSON file is provided with -i, each element should contain a "topic" and an
 func init() {
 	rootCmd.AddCommand(produceCmd)
 	produceCmd.Flags().StringVarP(&message, "message", "m", "", "Message to send")
-	produceCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Optional input file containing messages (one per line)")
+	produceCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Optional input file containing messages (JSON array or NDJSON), - for stdin")
+	produceCmd.Flags().StringVar(&lineMode, "line-mode", LineModeEnvelope, "How -i input is read: envelope (JSON envelopes) or raw (one record value per line)")
+	produceCmd.Flags().StringVarP(&produceKey, "key", "k", "", "Record key")
+	produceCmd.Flags().Int32VarP(&producePartition, "partition", "p", kafka.UnassignedPartition, "Partition to produce to (default: chosen by the partitioner)")
+	produceCmd.Flags().StringVar(&produceTimestamp, "timestamp", "", "Record timestamp (RFC3339, local time without timezone, or Unix milliseconds)")
+	produceCmd.Flags().StringArrayVarP(&produceHeaders, "header", "H", nil, "Record header as key=value (repeatable)")
+	produceCmd.Flags().IntVar(&maxInFlight, "max-in-flight", 10000, "Maximum number of messages from -i awaiting acknowledgement")
+	produceCmd.Flags().StringVar(&partitionerName, "partitioner", kafka.PartitionerDefault,
+		fmt.Sprintf("Partitioner for records without an explicit partition: %s", strings.Join(kafka.Partitioners, ", ")))
+	produceCmd.Flags().StringVar(&valueSubject, "value-subject", "", "Schema Registry subject to encode JSON values with, e.g. orders-value")
+	produceCmd.Flags().StringVar(&valueVersion, "value-version", kafka.LatestVersion, "Version of the --value-subject schema")
+	produceCmd.Flags().StringVar(&keySubject, "key-subject", "", "Schema Registry subject to encode JSON keys with, e.g. orders-key")
+	produceCmd.Flags().StringVar(&keyVersion, "key-version", kafka.LatestVersion, "Version of the --key-subject schema")
+	produceCmd.Flags().StringVar(&valueMessageType, "value-message", "", "Protobuf message type to encode values as, e.g. shop.Order (default: first message of the schema)")
+	produceCmd.Flags().StringVar(&keyMessageType, "key-message", "", "Protobuf message type to encode keys as (default: first message of the schema)")
 }
 
-func ProduceMessage(topic, jsonInput string, headers map[string]string) error {
+// produceBatch streams every envelope of reader through one client with asynchronous,
+// pipelined produces. Invalid envelopes and failed records don't stop the batch; they
+// are reported together once every record has completed.
+func produceBatch(reader messageReader, defaults MessageEnvelope, encoder *recordEncoder, opts ...kafka.ProducerOption) error {
 	cfg := kafka.LoadConfig()
 
-	// Create optimized producer client
-	client, err := cfg.NewProducerClient(topic)
+	client, err := cfg.CreateProducer(opts...)
 	if err != nil {
 		return fmt.Errorf("failed to create kafka client: %w", err)
 	}
 	defer client.Close()
 
-	// Convert headers to franz-go format
-	var franzHeaders []kgo.RecordHeader
-	for k, v := range headers {
-		franzHeaders = append(franzHeaders, kgo.RecordHeader{Key: k, Value: []byte(v)})
+	ctx := context.Background()
+	producer := newBatchProducer(client)
+	var readErr error
+	for i := 1; ; i++ {
+		msg, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// The input can't be resynchronized, stop reading but finish in-flight records
+			readErr = fmt.Errorf("failed to read message #%d: %w", i, err)
+			break
+		}
+
+		env := msg.withDefaults(defaults)
+		record, err := env.Record()
+		if err == nil {
+			err = encoder.Encode(ctx, env, record)
+		}
+		if err != nil {
+			producer.Fail(i, env.Topic, fmt.Errorf("invalid message: %w", err))
+			continue
+		}
+		producer.Produce(ctx, i, record)
 	}
 
-	// Create and send the record
-	record := &kgo.Record{
-		Topic:   topic,
-		Value:   []byte(jsonInput),
-		Headers: franzHeaders,
+	producer.Wait()
+	reportErr := producer.Report()
+	if readErr != nil {
+		return readErr
 	}
+	return reportErr
+}
 
-	// Produce the message synchronously
-	ctx := context.Background()
-	results := client.ProduceSync(ctx, record)
+// produceFlagsEnvelope builds an envelope holding the record metadata given on the command line
+func produceFlagsEnvelope(cmd *cobra.Command) (MessageEnvelope, error) {
+	var env MessageEnvelope
 
-	// Check for errors
-	for _, result := range results {
-		if result.Err != nil {
-			return fmt.Errorf("failed to produce message: %w", result.Err)
-		}
+	if cmd.Flags().Changed("key") {
+		key := produceKey
+		env.Key = &key
 	}
 
-	return nil
-}
+	if cmd.Flags().Changed("partition") {
+		if producePartition < 0 {
+			return env, fmt.Errorf("invalid --partition %d", producePartition)
+		}
+		partition := producePartition
+		env.Partition = &partition
+	}
 
-func HandleFileInput(inputFile string) ([]MessageEnvelope, error) {
-	color.Blue("📂 Reading file: %s", inputFile)
-	var inputs []MessageEnvelope
-	data, err := readFile(inputFile)
-	if err != nil {
-		return nil, err
+	if produceTimestamp != "" {
+		ts, err := parseRecordTimestamp(produceTimestamp)
+		if err != nil {
+			return env, fmt.Errorf("invalid --timestamp: %v", err)
+		}
+		env.Timestamp = &ts
 	}
 
-	var envelopes []MessageEnvelope
-	if err := json.Unmarshal(data, &envelopes); err != nil {
-		// Handle non array JSON (single object)
-		var singleMessage MessageEnvelope
-		if err := json.Unmarshal(data, &singleMessage); err != nil {
-			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	for _, h := range produceHeaders {
+		key, value, ok := strings.Cut(h, "=")
+		if !ok || key == "" {
+			return env, fmt.Errorf("invalid --header %q, expected key=value", h)
 		}
-		envelopes = append(envelopes, singleMessage)
+		env.Headers = append(env.Headers, EnvelopeHeader{Key: key, Value: value})
 	}
 
-	color.Blue("🧾 Found %d messages", len(envelopes))
+	return env, nil
+}
 
-	for _, obj := range envelopes {
-		inputs = append(inputs, MessageEnvelope{
-			Headers: obj.Headers,
-			Topic:   obj.Topic,
-			Message: obj.Message,
-		})
+// withDefaults fills Topic, Key, Partition and Timestamp from defaults when they are not set
+// and appends the default headers
+func (e MessageEnvelope) withDefaults(defaults MessageEnvelope) MessageEnvelope {
+	if e.Topic == "" {
+		e.Topic = defaults.Topic
+	}
+	if e.Key == nil {
+		e.Key, e.KeyEncoding = defaults.Key, EncodingNone
+	}
+	if e.Partition == nil {
+		e.Partition = defaults.Partition
 	}
+	if e.Timestamp == nil {
+		e.Timestamp = defaults.Timestamp
+	}
+	if len(defaults.Headers) > 0 {
+		headers := make(EnvelopeHeaders, 0, len(e.Headers)+len(defaults.Headers))
+		headers = append(headers, e.Headers...)
+		for _, h := range defaults.Headers {
+			value, _ := encodeBytes([]byte(h.Value), e.HeaderEncoding)
+			headers = append(headers, EnvelopeHeader{Key: h.Key, Value: value})
+		}
+		e.Headers = headers
+	}
+	return e
+}
 
-	return inputs, nil
+// parseRecordTimestamp accepts the same formats as extract, or Unix milliseconds
+func parseRecordTimestamp(s string) (time.Time, error) {
+	if millis, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(millis), nil
+	}
+	return parseTimeWithTimezone(s)
 }
 
-func readFile(path string) ([]byte, error) {
-	f, err := os.Open(path)
+// ProduceRecord sends a single record, honoring its key, partition, timestamp and headers
+func ProduceRecord(record *kgo.Record, opts ...kafka.ProducerOption) error {
+	cfg := kafka.LoadConfig()
+
+	// Create optimized producer client
+	client, err := cfg.NewProducerClient(record.Topic, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("open %s: %w", path, err)
+		return fmt.Errorf("failed to create kafka client: %w", err)
 	}
-	defer f.Close()
+	defer client.Close()
 
-	data, err := io.ReadAll(f)
-	if err != nil {
-		return nil, fmt.Errorf("read %s: %w", path, err)
+	// Produce the message synchronously
+	ctx := context.Background()
+	results := client.ProduceSync(ctx, record)
+
+	// Check for errors
+	for _, result := range results {
+		if result.Err != nil {
+			return fmt.Errorf("failed to produce message: %w", result.Err)
+		}
 	}
-	return data, nil
+
+	return nil
 }
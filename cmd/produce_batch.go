@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// maxReportedIndices caps how many message numbers are listed per distinct error
+const maxReportedIndices = 20
+
+// produceFailure records why a message of a batch could not be produced.
+// Index is the 1-based position of the message in the input.
+type produceFailure struct {
+	Index int
+	Topic string
+	Err   error
+}
+
+// batchProducer sends many records through a single long-lived client.
+// Records are produced asynchronously; the client blocks new produces once
+// its in-flight limit is reached (see kafka.WithMaxBufferedRecords).
+type batchProducer struct {
+	client *kgo.Client
+	wg     sync.WaitGroup
+	start  time.Time
+
+	mu       sync.Mutex
+	produced int64
+	bytes    int64
+	failures []produceFailure
+}
+
+func newBatchProducer(client *kgo.Client) *batchProducer {
+	return &batchProducer{
+		client: client,
+		start:  time.Now(),
+	}
+}
+
+// Produce queues a record. index is the 1-based position used in error reports.
+func (b *batchProducer) Produce(ctx context.Context, index int, record *kgo.Record) {
+	b.wg.Add(1)
+	size := int64(len(record.Key) + len(record.Value))
+	b.client.Produce(ctx, record, func(r *kgo.Record, err error) {
+		defer b.wg.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if err != nil {
+			b.failures = append(b.failures, produceFailure{Index: index, Topic: r.Topic, Err: err})
+			return
+		}
+		b.produced++
+		b.bytes += size
+	})
+}
+
+// Fail records a message that was rejected before being produced, e.g. an invalid envelope
+func (b *batchProducer) Fail(index int, topic string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = append(b.failures, produceFailure{Index: index, Topic: topic, Err: err})
+}
+
+// Wait blocks until every queued record has been acknowledged or has failed
+func (b *batchProducer) Wait() {
+	b.wg.Wait()
+}
+
+// Report prints the throughput summary and the aggregated failures, and returns
+// an error when at least one message failed
+func (b *batchProducer) Report() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	elapsed := time.Since(b.start)
+	total := b.produced + int64(len(b.failures))
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1e-9
+	}
+
+	color.Cyan("📊 Produced %d/%d messages in %s (%.0f msg/s, %.2f MB/s)",
+		b.produced, total, elapsed.Round(time.Millisecond),
+		float64(b.produced)/seconds, float64(b.bytes)/seconds/(1<<20))
+
+	if len(b.failures) == 0 {
+		return nil
+	}
+
+	for _, line := range summarizeFailures(b.failures) {
+		color.Red("❌ %s", line)
+	}
+	return fmt.Errorf("%d of %d messages failed", len(b.failures), total)
+}
+
+// summarizeFailures groups failures by error so each distinct cause is reported
+// once, together with the message numbers it affected
+func summarizeFailures(failures []produceFailure) []string {
+	byErr := make(map[string][]int)
+	var causes []string
+	for _, f := range failures {
+		msg := f.Err.Error()
+		if f.Topic != "" {
+			msg = fmt.Sprintf("topic '%s': %s", f.Topic, msg)
+		}
+		if _, ok := byErr[msg]; !ok {
+			causes = append(causes, msg)
+		}
+		byErr[msg] = append(byErr[msg], f.Index)
+	}
+
+	// Most frequent causes first
+	sort.SliceStable(causes, func(i, j int) bool { return len(byErr[causes[i]]) > len(byErr[causes[j]]) })
+
+	lines := make([]string, 0, len(causes))
+	for _, cause := range causes {
+		indices := byErr[cause]
+		sort.Ints(indices)
+
+		shown := indices
+		if len(shown) > maxReportedIndices {
+			shown = shown[:maxReportedIndices]
+		}
+		refs := make([]string, len(shown))
+		for i, idx := range shown {
+			refs[i] = fmt.Sprintf("#%d", idx)
+		}
+		list := strings.Join(refs, ", ")
+		if len(indices) > len(shown) {
+			list += fmt.Sprintf(" and %d more", len(indices)-len(shown))
+		}
+
+		noun := "messages"
+		if len(indices) == 1 {
+			noun = "message"
+		}
+		lines = append(lines, fmt.Sprintf("%d %s failed (%s): %s", len(indices), noun, list, cause))
+	}
+	return lines
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRecordTimestamp(t *testing.T) {
+	ts, err := parseRecordTimestamp("1759935600123")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if ts.UnixMilli() != 1759935600123 {
+		t.Fatalf("expected Unix milliseconds to be honored, got %d", ts.UnixMilli())
+	}
+
+	ts, err = parseRecordTimestamp("2025-10-08T15:00:00+02:00")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !ts.Equal(time.Date(2025, 10, 8, 13, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected timestamp %v", ts)
+	}
+
+	if _, err := parseRecordTimestamp("yesterday"); err == nil {
+		t.Fatalf("expected error for invalid timestamp")
+	}
+}
+
+func TestMessageEnvelopeWithDefaults(t *testing.T) {
+	key := "default-key"
+	partition := int32(2)
+	ts := time.UnixMilli(1000)
+	defaults := MessageEnvelope{
+		Key:       &key,
+		Partition: &partition,
+		Timestamp: &ts,
+		Headers:   EnvelopeHeaders{{Key: "source", Value: "cli"}},
+	}
+
+	ownKey := "own-key"
+	env := MessageEnvelope{
+		Topic:          "events",
+		Key:            &ownKey,
+		Headers:        EnvelopeHeaders{{Key: "trace", Value: "YQ=="}},
+		HeaderEncoding: EncodingBase64,
+	}.withDefaults(defaults)
+
+	if *env.Key != "own-key" {
+		t.Fatalf("expected envelope key to win, got %q", *env.Key)
+	}
+	if env.Partition == nil || *env.Partition != 2 || env.Timestamp == nil {
+		t.Fatalf("expected partition and timestamp defaults, got %+v", env)
+	}
+
+	record, err := env.Record()
+	if err != nil {
+		t.Fatalf("failed to build record: %v", err)
+	}
+	if len(record.Headers) != 2 || string(record.Headers[0].Value) != "a" || string(record.Headers[1].Value) != "cli" {
+		t.Fatalf("unexpected headers %+v", record.Headers)
+	}
+}
+
+func TestSummarizeFailures(t *testing.T) {
+	leader := errors.New("NOT_LEADER_FOR_PARTITION")
+	var failures []produceFailure
+	for i := 30; i > 0; i-- {
+		failures = append(failures, produceFailure{Index: i, Topic: "events", Err: leader})
+	}
+	failures = append(failures, produceFailure{Index: 31, Err: errors.New("invalid message: missing Topic")})
+
+	lines := summarizeFailures(failures)
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per distinct error, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "30 messages failed (#1, #2, ") || !strings.Contains(lines[0], "#20 and 10 more") {
+		t.Fatalf("unexpected summary %q", lines[0])
+	}
+	if !strings.Contains(lines[0], "topic 'events': NOT_LEADER_FOR_PARTITION") {
+		t.Fatalf("expected topic and cause in summary, got %q", lines[0])
+	}
+	if lines[1] != "1 message failed (#31): invalid message: missing Topic" {
+		t.Fatalf("unexpected summary %q", lines[1])
+	}
+}
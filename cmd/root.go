@@ -1,25 +1,38 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
-	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+// ProfileEnv selects a profile when --profile is not given
+const ProfileEnv = "KAFKA_CLI_PROFILE"
+
+var (
+	profilesFile string
+	profileName  string
+	awsProfile   string
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "kafka-cli",
-	Short: "A brief description of your application",
-	Long: `A longer description that spans multiple lines and likely contains
-examples and usage of using your application. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	// Uncomment the following line if your bare application
-	// has an action associated with it:
-	// Run: func(cmd *cobra.Command, args []string) { },
+	Short: "Produce, consume, extract and administer Kafka topics",
+	Long: `kafka-cli produces, consumes and extracts Kafka messages and manages topics,
+consumer groups and Schema Registry subjects, on local clusters and on AWS MSK
+with IAM authentication.
+
+Connection settings come from environment variables (and a .env file), or from
+a named profile of ~/.kafka-cli.yaml selected with --profile. Environment
+variables override the profile; those of .env only override the current
+context, not a profile selected with --profile.`,
+	PersistentPreRunE: activateProfile,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -31,17 +44,70 @@ func Execute() {
 	}
 }
 
+// resolveProfilesFile returns the profiles file path and whether it was given explicitly
+func resolveProfilesFile() (string, bool, error) {
+	if profilesFile != "" {
+		return profilesFile, true, nil
+	}
+	path, err := kafka.DefaultProfilesPath()
+	return path, false, err
+}
+
+// selectedProfile returns the profile named by --profile or KAFKA_CLI_PROFILE, if any
+func selectedProfile() string {
+	if profileName != "" {
+		return profileName
+	}
+	return os.Getenv(ProfileEnv)
+}
+
+// activateProfile loads the selected profile, or the current context, so that
+// kafka.NewConfig falls back to it, and applies the setting flags.
+// A missing default profiles file is not an error.
+func activateProfile(cmd *cobra.Command, args []string) error {
+	kafka.OverrideSetting("AWS_PROFILE", awsProfile)
+
+	path, explicit, err := resolveProfilesFile()
+	if err != nil {
+		return err
+	}
+	name := selectedProfile()
+
+	file, err := kafka.LoadProfiles(path)
+	if errors.Is(err, kafka.ErrProfilesFileNotFound) && !explicit && name == "" {
+		kafka.UseProfile(nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	profile, err := file.Profile(name)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		// Chosen for this run, so .env must not redirect it
+		kafka.SelectProfile(profile)
+	} else {
+		kafka.UseProfile(profile)
+	}
+	return nil
+}
+
 func init() {
 	// Load .env if present so Kubernetes jobs or local runs can simply mount the file
-	_ = godotenv.Load()
-
-	// Here you will define your flags and configuration settings.
-	// Cobra supports persistent flags, which, if defined here,
-	// will be global for your application.
+	_ = kafka.LoadDotEnv(".env")
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.kafka-cli.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profilesFile, "config", "", "Profiles file (default $HOME/"+kafka.DefaultProfilesFile+")")
+	rootCmd.PersistentFlags().StringVar(&awsProfile, "aws-profile", "", "AWS profile for MSK IAM authentication (default: $AWS_PROFILE or aws.profile of the profile)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", fmt.Sprintf("Profile (context) to use, also --context (default: $%s or current-context)", ProfileEnv))
 
-	// Cobra also supports local flags, which will only run
-	// when this action is called directly.
-	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	// --context is an alias of --profile
+	rootCmd.SetGlobalNormalizationFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "context" {
+			name = "profile"
+		}
+		return pflag.NormalizedName(name)
+	})
 }
@@ -0,0 +1,469 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+var (
+	schemaOutput        string
+	schemaDeleted       bool
+	schemaPrefix        string
+	getSchemaVersion    string
+	checkSchemaVersion  string
+	deleteSchemaVersion string
+	schemaID            int
+	schemaFile          string
+	schemaType          string
+	schemaReferences    []string
+	schemaCompatibility string
+	schemaPermanent     bool
+	schemaDeleteYes     bool
+)
+
+// subjectVersions is the versions output of a subject
+type subjectVersions struct {
+	Subject  string
+	Versions []int
+}
+
+// compatibilityLevel is the compatibility output of a subject or of the registry
+type compatibilityLevel struct {
+	Subject       string `json:",omitempty"`
+	Compatibility string
+}
+
+// compatibilityReport is the check output
+type compatibilityReport struct {
+	Subject    string
+	Version    string
+	Compatible bool
+	Messages   []string
+}
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Manage Schema Registry subjects and schemas",
+	Long: `Manage the subjects, schemas and compatibility levels of the Schema Registry
+configured with SCHEMA_REGISTRY_URL, SCHEMA_REGISTRY_USERNAME/SCHEMA_REGISTRY_PASSWORD
+or SCHEMA_REGISTRY_BEARER_TOKEN.`,
+}
+
+var listSubjectsCmd = &cobra.Command{
+	Use:   "subjects",
+	Short: "List subjects",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := newSchemaCommandRegistry()
+		if err != nil {
+			return err
+		}
+
+		all, err := registry.Subjects(context.Background(), schemaDeleted)
+		if err != nil {
+			return err
+		}
+		subjects := make([]string, 0, len(all))
+		for _, s := range all {
+			if strings.HasPrefix(s, schemaPrefix) {
+				subjects = append(subjects, s)
+			}
+		}
+		sort.Strings(subjects)
+
+		if schemaOutput == OutputJSON {
+			return writeJSON(subjects)
+		}
+		if len(subjects) == 0 {
+			color.Yellow("No subjects found")
+			return nil
+		}
+		color.Blue("Subjects:")
+		for _, s := range subjects {
+			fmt.Printf("  %s\n", s)
+		}
+		return nil
+	},
+}
+
+var listVersionsCmd = &cobra.Command{
+	Use:   "versions <subject>",
+	Short: "List the versions of a subject",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := newSchemaCommandRegistry()
+		if err != nil {
+			return err
+		}
+
+		versions, err := registry.Versions(context.Background(), args[0], schemaDeleted)
+		if err != nil {
+			return err
+		}
+		sort.Ints(versions)
+
+		if schemaOutput == OutputJSON {
+			return writeJSON(subjectVersions{Subject: args[0], Versions: versions})
+		}
+		color.Blue("Versions of %s:", args[0])
+		for _, v := range versions {
+			fmt.Printf("  %d\n", v)
+		}
+		return nil
+	},
+}
+
+var getSchemaCmd = &cobra.Command{
+	Use:   "get [subject]",
+	Short: "Show a schema by subject and version, or by ID",
+	Long: `Show the latest schema of a subject, the version given with --version, or the
+schema with the ID given with --id.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (len(args) == 1) == cmd.Flags().Changed("id") {
+			return fmt.Errorf("exactly one of a subject or --id is required")
+		}
+		registry, err := newSchemaCommandRegistry()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		var schema *kafka.Schema
+		if len(args) == 1 {
+			schema, err = registry.SubjectSchema(ctx, args[0], getSchemaVersion)
+		} else {
+			schema, err = registry.SchemaByID(ctx, schemaID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if schemaOutput == OutputJSON {
+			return writeJSON(schema)
+		}
+		printSchema(schema)
+		return nil
+	},
+}
+
+var registerSchemaCmd = &cobra.Command{
+	Use:   "register <subject>",
+	Short: "Register a schema from a file",
+	Long: `Register the schema in --file under a subject. The schema type is taken from
+--type, or from the file extension: .avsc for Avro, .proto for Protobuf and .json
+for JSON Schema. Schemas of other subjects can be referenced with --reference.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subject := args[0]
+		schema, err := readSchemaFile(schemaFile, schemaType, schemaReferences)
+		if err != nil {
+			return err
+		}
+		registry, err := newSchemaCommandRegistry()
+		if err != nil {
+			return err
+		}
+
+		id, err := registry.RegisterSchema(context.Background(), subject, schema)
+		if err != nil {
+			return err
+		}
+		if schemaOutput == OutputJSON {
+			return writeJSON(map[string]any{"Subject": subject, "ID": id})
+		}
+		color.Green("✅ Registered %s schema under subject '%s' with ID %d", schema.Type(), subject, id)
+		return nil
+	},
+}
+
+var checkSchemaCmd = &cobra.Command{
+	Use:   "check <subject>",
+	Short: "Test whether a local schema is compatible with a subject",
+	Long: `Test the schema in --file against the latest version of a subject, or the
+version given with --version, using the subject's compatibility level. The
+command fails when the schema is not compatible.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subject := args[0]
+		schema, err := readSchemaFile(schemaFile, schemaType, schemaReferences)
+		if err != nil {
+			return err
+		}
+		registry, err := newSchemaCommandRegistry()
+		if err != nil {
+			return err
+		}
+
+		result, err := registry.CheckCompatibility(context.Background(), subject, checkSchemaVersion, schema)
+		if err != nil {
+			return err
+		}
+		report := compatibilityReport{
+			Subject:    subject,
+			Version:    checkSchemaVersion,
+			Compatible: result.Compatible,
+			Messages:   result.Messages,
+		}
+		if report.Messages == nil {
+			report.Messages = []string{}
+		}
+
+		if schemaOutput == OutputJSON {
+			if err := writeJSON(report); err != nil {
+				return err
+			}
+		} else if result.Compatible {
+			color.Green("✅ Schema is compatible with %s version %s", subject, checkSchemaVersion)
+		} else {
+			color.Red("❌ Schema is not compatible with %s version %s", subject, checkSchemaVersion)
+			for _, m := range result.Messages {
+				fmt.Printf("  - %s\n", m)
+			}
+		}
+		if !result.Compatible {
+			return fmt.Errorf("schema is not compatible with subject %s", subject)
+		}
+		return nil
+	},
+}
+
+var compatibilityCmd = &cobra.Command{
+	Use:   "compatibility [subject]",
+	Short: "Get or set the compatibility level of a subject or of the registry",
+	Long: fmt.Sprintf(`Show the compatibility level of a subject (falling back to the global level),
+or the global level without a subject. With --set, change it to one of:
+%s.`, strings.Join(kafka.CompatibilityLevels, ", ")),
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subject := ""
+		if len(args) == 1 {
+			subject = args[0]
+		}
+		registry, err := newSchemaCommandRegistry()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		level := compatibilityLevel{Subject: subject}
+		if schemaCompatibility != "" {
+			level.Compatibility, err = registry.SetCompatibility(ctx, subject, schemaCompatibility)
+		} else {
+			level.Compatibility, err = registry.Compatibility(ctx, subject)
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case schemaOutput == OutputJSON:
+			return writeJSON(level)
+		case schemaCompatibility != "":
+			color.Green("✅ Compatibility of %s set to %s", compatibilityScope(subject), level.Compatibility)
+		default:
+			fmt.Printf("Compatibility of %s: %s\n", compatibilityScope(subject), level.Compatibility)
+		}
+		return nil
+	},
+}
+
+var deleteSchemaCmd = &cobra.Command{
+	Use:   "delete <subject>",
+	Short: "Delete a subject or one of its versions",
+	Long: `Soft delete every version of a subject, or only the version given with --version.
+Soft deleted schemas can still be read by ID. With --permanent, the schemas are
+hard deleted and their IDs can't be resolved anymore.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subject := args[0]
+		target := fmt.Sprintf("every version of subject '%s'", subject)
+		if deleteSchemaVersion != "" {
+			target = fmt.Sprintf("version %s of subject '%s'", deleteSchemaVersion, subject)
+		}
+		mode := "Soft delete"
+		if schemaPermanent {
+			mode = "Permanently delete"
+		}
+		if !schemaDeleteYes && !confirm(fmt.Sprintf("%s %s?", mode, target)) {
+			color.Yellow("Aborted")
+			return nil
+		}
+
+		registry, err := newSchemaCommandRegistry()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		var versions []int
+		if deleteSchemaVersion != "" {
+			var version int
+			version, err = registry.DeleteVersion(ctx, subject, deleteSchemaVersion, schemaPermanent)
+			versions = []int{version}
+		} else {
+			versions, err = registry.DeleteSubject(ctx, subject, schemaPermanent)
+		}
+		if err != nil {
+			return err
+		}
+		sort.Ints(versions)
+
+		if schemaOutput == OutputJSON {
+			return writeJSON(subjectVersions{Subject: subject, Versions: versions})
+		}
+		color.Green("🗑️  Deleted versions %s of subject '%s'", joinInts(versions), subject)
+		return nil
+	},
+}
+
+// newSchemaCommandRegistry validates the output flag and creates the registry client
+func newSchemaCommandRegistry() (*kafka.SchemaRegistry, error) {
+	if schemaOutput != OutputTable && schemaOutput != OutputJSON {
+		return nil, fmt.Errorf("unsupported --output %q (expected %s or %s)", schemaOutput, OutputTable, OutputJSON)
+	}
+	return kafka.LoadConfig().NewSchemaRegistry()
+}
+
+// readSchemaFile reads a schema to register or check. schemaType may be empty to
+// infer it from the file extension, references are given as name=subject:version.
+func readSchemaFile(path, schemaType string, references []string) (kafka.Schema, error) {
+	if path == "" {
+		return kafka.Schema{}, fmt.Errorf("--file is required")
+	}
+	in, err := openInput(path)
+	if err != nil {
+		return kafka.Schema{}, err
+	}
+	defer in.Close()
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return kafka.Schema{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if schemaType == "" {
+		schemaType = schemaTypeFromPath(path)
+	}
+	schemaType = strings.ToUpper(schemaType)
+	if schemaType != kafka.SchemaTypeAvro && schemaType != kafka.SchemaTypeProtobuf && schemaType != kafka.SchemaTypeJSON {
+		return kafka.Schema{}, fmt.Errorf("unsupported --type %q (expected %s, %s or %s)", schemaType, kafka.SchemaTypeAvro, kafka.SchemaTypeProtobuf, kafka.SchemaTypeJSON)
+	}
+
+	refs, err := parseSchemaReferences(references)
+	if err != nil {
+		return kafka.Schema{}, err
+	}
+	return kafka.Schema{SchemaType: schemaType, Schema: string(data), References: refs}, nil
+}
+
+// schemaTypeFromPath infers the schema type from a file extension, defaulting to Avro
+func schemaTypeFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".proto":
+		return kafka.SchemaTypeProtobuf
+	case ".json":
+		return kafka.SchemaTypeJSON
+	default:
+		return kafka.SchemaTypeAvro
+	}
+}
+
+// parseSchemaReferences parses --reference values of the form name=subject:version
+func parseSchemaReferences(values []string) ([]kafka.SchemaReference, error) {
+	refs := make([]kafka.SchemaReference, 0, len(values))
+	for _, v := range values {
+		name, target, ok := strings.Cut(v, "=")
+		idx := strings.LastIndex(target, ":")
+		if !ok || name == "" || idx <= 0 {
+			return nil, fmt.Errorf("invalid --reference %q, expected name=subject:version", v)
+		}
+		version, err := strconv.Atoi(target[idx+1:])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid version in --reference %q", v)
+		}
+		refs = append(refs, kafka.SchemaReference{Name: name, Subject: target[:idx], Version: version})
+	}
+	return refs, nil
+}
+
+func printSchema(schema *kafka.Schema) {
+	if schema.Subject != "" {
+		color.Cyan("📜 Subject %s version %d (ID %d, %s)", schema.Subject, schema.Version, schema.ID, schema.Type())
+	} else {
+		color.Cyan("📜 Schema %d (%s)", schema.ID, schema.Type())
+	}
+	for _, ref := range schema.References {
+		fmt.Printf("Reference: %s → %s version %d\n", ref.Name, ref.Subject, ref.Version)
+	}
+	fmt.Println()
+
+	var buf bytes.Buffer
+	if schema.Type() != kafka.SchemaTypeProtobuf && json.Indent(&buf, []byte(schema.Schema), "", "  ") == nil {
+		buf.WriteByte('\n')
+		buf.WriteTo(os.Stdout)
+		return
+	}
+	fmt.Println(strings.TrimRight(schema.Schema, "\n"))
+}
+
+// compatibilityScope names the subject, or the global level
+func compatibilityScope(subject string) string {
+	if subject == "" {
+		return "the registry (global)"
+	}
+	return fmt.Sprintf("subject '%s'", subject)
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.AddCommand(listSubjectsCmd)
+	schemaCmd.AddCommand(listVersionsCmd)
+	schemaCmd.AddCommand(getSchemaCmd)
+	schemaCmd.AddCommand(registerSchemaCmd)
+	schemaCmd.AddCommand(checkSchemaCmd)
+	schemaCmd.AddCommand(compatibilityCmd)
+	schemaCmd.AddCommand(deleteSchemaCmd)
+
+	schemaCmd.PersistentFlags().StringVarP(&schemaOutput, "output", "o", OutputTable, "Output format: table or json")
+
+	listSubjectsCmd.Flags().BoolVar(&schemaDeleted, "deleted", false, "Include soft deleted subjects")
+	listSubjectsCmd.Flags().StringVar(&schemaPrefix, "prefix", "", "Only list subjects starting with this prefix")
+	listVersionsCmd.Flags().BoolVar(&schemaDeleted, "deleted", false, "Include soft deleted versions")
+
+	getSchemaCmd.Flags().StringVar(&getSchemaVersion, "version", kafka.LatestVersion, "Version of the subject")
+	getSchemaCmd.Flags().IntVar(&schemaID, "id", 0, "Schema ID, instead of a subject")
+
+	for _, c := range []*cobra.Command{registerSchemaCmd, checkSchemaCmd} {
+		c.Flags().StringVarP(&schemaFile, "file", "f", "", "Schema file, - for stdin")
+		c.Flags().StringVar(&schemaType, "type", "", "Schema type: AVRO, PROTOBUF or JSON (default: from the file extension)")
+		c.Flags().StringArrayVar(&schemaReferences, "reference", nil, "Referenced schema as name=subject:version (repeatable)")
+	}
+	checkSchemaCmd.Flags().StringVar(&checkSchemaVersion, "version", kafka.LatestVersion, "Version of the subject to test against")
+
+	compatibilityCmd.Flags().StringVar(&schemaCompatibility, "set", "", "New compatibility level")
+
+	deleteSchemaCmd.Flags().StringVar(&deleteSchemaVersion, "version", "", "Only delete this version (a number or latest)")
+	deleteSchemaCmd.Flags().BoolVar(&schemaPermanent, "permanent", false, "Hard delete, after a soft delete if needed")
+	deleteSchemaCmd.Flags().BoolVarP(&schemaDeleteYes, "yes", "y", false, "Delete without asking for confirmation")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+func TestParseSchemaReferences(t *testing.T) {
+	refs, err := parseSchemaReferences([]string{"shop.Customer=customer-value:2", "common.proto=team:common:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []kafka.SchemaReference{
+		{Name: "shop.Customer", Subject: "customer-value", Version: 2},
+		{Name: "common.proto", Subject: "team:common", Version: 1},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Fatalf("parseSchemaReferences() = %+v, want %+v", refs, want)
+	}
+
+	for _, invalid := range []string{"customer-value:2", "=customer:1", "a=customer", "a=customer:latest", "a=:1"} {
+		if _, err := parseSchemaReferences([]string{invalid}); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestReadSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		path     string
+		flagType string
+		want     string
+	}{
+		{write("order.avsc", `"string"`), "", kafka.SchemaTypeAvro},
+		{write("order.proto", `syntax = "proto3";`), "", kafka.SchemaTypeProtobuf},
+		{write("order.json", `{"type":"object"}`), "", kafka.SchemaTypeJSON},
+		{write("order.schema", `{"type":"object"}`), "json", kafka.SchemaTypeJSON},
+	}
+	for _, tt := range tests {
+		schema, err := readSchemaFile(tt.path, tt.flagType, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if schema.SchemaType != tt.want || schema.Schema == "" {
+			t.Errorf("readSchemaFile(%s) = %+v, want type %s", tt.path, schema, tt.want)
+		}
+	}
+
+	if _, err := readSchemaFile(tests[0].path, "xml", nil); err == nil {
+		t.Fatalf("expected an unsupported type error")
+	}
+	if _, err := readSchemaFile("", "", nil); err == nil {
+		t.Fatalf("expected --file to be required")
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+// schemaHelp is appended to the help of the commands reading records
+const schemaHelp = `
+Schema Registry:
+  When SCHEMA_REGISTRY_URL is set, keys and values in the Confluent wire format
+  (magic byte 0 and a 4-byte schema ID) are decoded to JSON with their Avro,
+  Protobuf or JSON Schema schema before being filtered and printed. Data that
+  can't be decoded is kept as is. Use --no-decode to keep the raw bytes.
+`
+
+// recordSchemaIDs holds the schema IDs a record key and value were decoded with, 0 if not
+// decoded, and their Protobuf message types
+type recordSchemaIDs struct {
+	Key              int
+	Value            int
+	KeyMessageType   string
+	ValueMessageType string
+}
+
+type recordSchemaIDsKey struct{}
+
+// schemaIDsOf returns the schema IDs set by recordDecoder.Decode
+func schemaIDsOf(r *kgo.Record) recordSchemaIDs {
+	if r.Context == nil {
+		return recordSchemaIDs{}
+	}
+	ids, _ := r.Context.Value(recordSchemaIDsKey{}).(recordSchemaIDs)
+	return ids
+}
+
+// schemaRetryDelay is how long a schema is not fetched again after a transient error
+const schemaRetryDelay = 10 * time.Second
+
+// recordDecoder replaces keys and values written in the Schema Registry wire
+// format by their JSON form. A nil decoder leaves records unchanged.
+type recordDecoder struct {
+	registry *kafka.SchemaRegistry
+	warn     func(format string, args ...any)
+
+	mu      sync.Mutex
+	failed  map[int]bool      // schemas that don't exist or can't be parsed
+	retryAt map[int]time.Time // schemas not fetched because of a transient error
+	warned  map[int]bool      // schemas some data could not be decoded with
+}
+
+// newRecordDecoder returns a decoder using the configured registry, or nil when
+// disabled or when no registry is configured. warn reports data that can't be decoded.
+func newRecordDecoder(cfg *kafka.Config, disabled bool, warn func(format string, args ...any)) (*recordDecoder, error) {
+	if disabled {
+		return nil, nil
+	}
+	registry, err := cfg.NewSchemaRegistry()
+	if errors.Is(err, kafka.ErrSchemaRegistryNotConfigured) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recordDecoder{
+		registry: registry,
+		warn:     warn,
+		failed:   make(map[int]bool),
+		retryAt:  make(map[int]time.Time),
+		warned:   make(map[int]bool),
+	}, nil
+}
+
+// Decode decodes the key and value of r in place and records the schema IDs
+// in r.Context. Failures leave the data as is and are reported once per schema,
+// or once per retry after a transient error.
+func (d *recordDecoder) Decode(ctx context.Context, r *kgo.Record) {
+	if d == nil {
+		return
+	}
+	var ids recordSchemaIDs
+	r.Key, ids.Key, ids.KeyMessageType = d.decode(ctx, r.Key, "key")
+	r.Value, ids.Value, ids.ValueMessageType = d.decode(ctx, r.Value, "value")
+	if ids == (recordSchemaIDs{}) {
+		return
+	}
+	parent := r.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	r.Context = context.WithValue(parent, recordSchemaIDsKey{}, ids)
+}
+
+func (d *recordDecoder) decode(ctx context.Context, data []byte, what string) ([]byte, int, string) {
+	id, _, ok := kafka.ParseWireFormat(data)
+	if !ok {
+		return data, 0, ""
+	}
+	d.mu.Lock()
+	skip := d.failed[id] || time.Now().Before(d.retryAt[id])
+	d.mu.Unlock()
+	if skip {
+		return data, 0, ""
+	}
+
+	decoded, err := d.registry.Decode(ctx, data)
+	if err != nil {
+		d.fail(id, what, err)
+		return data, 0, ""
+	}
+	return decoded.Data, id, decoded.MessageType
+}
+
+// fail records and reports a decoding failure. A schema that doesn't exist or
+// can't be parsed is never fetched again, while a transient error, e.g. a timeout
+// or an expired token, only holds the schema for schemaRetryDelay. Data that
+// doesn't match its schema leaves the schema in use.
+func (d *recordDecoder) fail(id int, what string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case kafka.IsNotFound(err) || errors.Is(err, kafka.ErrInvalidSchema):
+		d.failed[id] = true
+	case errors.Is(err, kafka.ErrUndecodable):
+		if d.warned[id] {
+			return
+		}
+		d.warned[id] = true
+	default:
+		d.retryAt[id] = time.Now().Add(schemaRetryDelay)
+		if d.warn != nil {
+			d.warn("⚠️  Can't fetch schema %d, keeping raw bytes for %s: %v", id, schemaRetryDelay, err)
+		}
+		return
+	}
+	if d.warn != nil {
+		d.warn("⚠️  Can't decode %s with schema %d, keeping raw bytes: %v", what, id, err)
+	}
+}
+
+// recordEncoder encodes produced keys and values with Schema Registry schemas:
+// the subject schemas given on the command line, or the schema IDs of the envelope.
+// Protobuf data is written as the message types given on the command line, or
+// those of the envelope along with its schema IDs.
+type recordEncoder struct {
+	registry         *kafka.SchemaRegistry
+	key              *kafka.Schema
+	value            *kafka.Schema
+	keyMessageType   string
+	valueMessageType string
+}
+
+// newRecordEncoder resolves the key and value subjects. The registry is only
+// required when a subject is given or an envelope carries a schema ID.
+func newRecordEncoder(ctx context.Context, cfg *kafka.Config, keySubject, keyVersion, keyMessageType, valueSubject, valueVersion, valueMessageType string) (*recordEncoder, error) {
+	registry, err := cfg.NewSchemaRegistry()
+	if err != nil && !errors.Is(err, kafka.ErrSchemaRegistryNotConfigured) {
+		return nil, err
+	}
+	if registry == nil && (keySubject != "" || valueSubject != "") {
+		return nil, fmt.Errorf("--key-subject and --value-subject need a schema registry: %w", err)
+	}
+
+	e := &recordEncoder{registry: registry, keyMessageType: keyMessageType, valueMessageType: valueMessageType}
+	if keySubject != "" {
+		if e.key, err = registry.SubjectSchema(ctx, keySubject, keyVersion); err != nil {
+			return nil, err
+		}
+	}
+	if valueSubject != "" {
+		if e.value, err = registry.SubjectSchema(ctx, valueSubject, valueVersion); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Encode encodes the key and value of record, built from env, in the wire format.
+// Tombstones and missing keys are left as is.
+func (e *recordEncoder) Encode(ctx context.Context, env MessageEnvelope, record *kgo.Record) error {
+	keySchema, err := e.schema(ctx, e.key, env.KeySchemaID, "KeySchemaID")
+	if err != nil {
+		return err
+	}
+	if keySchema != nil && record.Key != nil {
+		messageType := e.messageType(e.keyMessageType, e.key, env.KeyMessageType)
+		if record.Key, err = e.registry.Encode(ctx, keySchema, record.Key, messageType); err != nil {
+			return fmt.Errorf("invalid Key: %w", err)
+		}
+	}
+
+	valueSchema, err := e.schema(ctx, e.value, env.ValueSchemaID, "ValueSchemaID")
+	if err != nil {
+		return err
+	}
+	if valueSchema != nil && record.Value != nil {
+		messageType := e.messageType(e.valueMessageType, e.value, env.ValueMessageType)
+		if record.Value, err = e.registry.Encode(ctx, valueSchema, record.Value, messageType); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+	}
+	return nil
+}
+
+// schema returns the subject schema when one was given, else the schema with the envelope ID
+func (e *recordEncoder) schema(ctx context.Context, subject *kafka.Schema, id *int, field string) (*kafka.Schema, error) {
+	switch {
+	case subject != nil:
+		return subject, nil
+	case id == nil:
+		return nil, nil
+	case e.registry == nil:
+		return nil, fmt.Errorf("%s requires a schema registry: %w", field, kafka.ErrSchemaRegistryNotConfigured)
+	}
+	return e.registry.SchemaByID(ctx, *id)
+}
+
+// messageType returns the Protobuf message type given on the command line, else
+// the one of the envelope when the envelope schema ID is used
+func (e *recordEncoder) messageType(flag string, subject *kafka.Schema, envelope string) string {
+	if flag != "" || subject != nil {
+		return flag
+	}
+	return envelope
+}
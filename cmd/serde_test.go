@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+// newTestRegistryConfig starts a stand-in registry with a single Avro schema, ID 3,
+// registered as version 1 of orders-value
+func newTestRegistryConfig(t *testing.T) *kafka.Config {
+	t.Helper()
+	const schema = `"{\"type\":\"record\",\"name\":\"Order\",\"fields\":[{\"name\":\"id\",\"type\":\"long\"}]}"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schemas/ids/3":
+			w.Write([]byte(`{"schema":` + schema + `}`))
+		case "/subjects/orders-value/versions/latest", "/subjects/orders-value/versions/1":
+			w.Write([]byte(`{"subject":"orders-value","version":1,"id":3,"schema":` + schema + `}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &kafka.Config{SchemaRegistryURL: srv.URL}
+}
+
+func TestRecordDecoderAndEncoderRoundTrip(t *testing.T) {
+	cfg := newTestRegistryConfig(t)
+	ctx := context.Background()
+
+	encoder, err := newRecordEncoder(ctx, cfg, "", "", "", "orders-value", kafka.LatestVersion, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &kgo.Record{Topic: "orders", Key: []byte("k1"), Value: []byte(`{"id":42}`)}
+	if err := encoder.Encode(ctx, MessageEnvelope{}, record); err != nil {
+		t.Fatal(err)
+	}
+	if id, _, ok := kafka.ParseWireFormat(record.Value); !ok || id != 3 {
+		t.Fatalf("expected value encoded with schema 3, got %v", record.Value)
+	}
+	if string(record.Key) != "k1" {
+		t.Fatalf("key should be left as is without --key-subject, got %q", record.Key)
+	}
+
+	decoder, err := newRecordDecoder(cfg, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder.Decode(ctx, record)
+	if string(record.Value) != `{"id":42}` {
+		t.Fatalf("decoded value = %q", record.Value)
+	}
+	env := newMessageEnvelope(record, EncodingAuto)
+	if env.ValueSchemaID == nil || *env.ValueSchemaID != 3 || env.KeySchemaID != nil {
+		t.Fatalf("unexpected schema IDs in %+v", env)
+	}
+	if string(env.Message) != `{"id":42}` {
+		t.Fatalf("expected the decoded value as Message, got %s", env.Message)
+	}
+
+	// Replaying the envelope encodes the value again with its schema ID
+	replayed, err := env.Record()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := newRecordEncoder(ctx, cfg, "", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.Encode(ctx, env, replayed); err != nil {
+		t.Fatal(err)
+	}
+	if id, _, ok := kafka.ParseWireFormat(replayed.Value); !ok || id != 3 {
+		t.Fatalf("expected replayed value encoded with schema 3, got %v", replayed.Value)
+	}
+}
+
+func TestRecordDecoderKeepsUndecodableData(t *testing.T) {
+	cfg := newTestRegistryConfig(t)
+	var warnings []string
+	decoder, err := newRecordDecoder(cfg, false, func(format string, args ...any) {
+		warnings = append(warnings, format)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := kafka.AppendWireFormat(9, []byte{1, 2})
+	for i := 0; i < 2; i++ {
+		record := &kgo.Record{Value: raw}
+		decoder.Decode(context.Background(), record)
+		if string(record.Value) != string(raw) || schemaIDsOf(record) != (recordSchemaIDs{}) {
+			t.Fatalf("undecodable value should be kept, got %v", record.Value)
+		}
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("expected a single warning for schema 9, got %d", len(warnings))
+	}
+}
+
+func TestRecordDecoderRetriesTransientErrors(t *testing.T) {
+	const schema = `"{\"type\":\"record\",\"name\":\"Order\",\"fields\":[{\"name\":\"id\",\"type\":\"long\"}]}"`
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"schema":` + schema + `}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var warnings []string
+	decoder, err := newRecordDecoder(&kafka.Config{SchemaRegistryURL: srv.URL}, false, func(format string, args ...any) {
+		warnings = append(warnings, format)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	encoded := kafka.AppendWireFormat(3, []byte{0x54}) // id 42
+	garbage := kafka.AppendWireFormat(3, []byte{0xff})
+
+	// The schema is held after the 503, without asking the registry again
+	for i := 0; i < 2; i++ {
+		record := &kgo.Record{Value: encoded}
+		decoder.Decode(ctx, record)
+		if string(record.Value) != string(encoded) {
+			t.Fatalf("expected raw bytes while the registry fails, got %q", record.Value)
+		}
+	}
+	if n := requests.Load(); n != 1 || len(warnings) != 1 {
+		t.Fatalf("expected 1 request and 1 warning, got %d and %d", n, len(warnings))
+	}
+
+	// Once the delay is over, the schema is fetched again
+	decoder.retryAt[3] = time.Time{}
+	record := &kgo.Record{Value: encoded}
+	decoder.Decode(ctx, record)
+	if string(record.Value) != `{"id":42}` {
+		t.Fatalf("decoded value = %q", record.Value)
+	}
+
+	// Data that doesn't match the schema is reported once and doesn't hold the schema
+	for i := 0; i < 2; i++ {
+		decoder.Decode(ctx, &kgo.Record{Value: garbage})
+	}
+	record = &kgo.Record{Value: encoded}
+	decoder.Decode(ctx, record)
+	if string(record.Value) != `{"id":42}` || len(warnings) != 2 {
+		t.Fatalf("decoded value = %q with %d warnings", record.Value, len(warnings))
+	}
+}
+
+func TestRecordDecoderDisabled(t *testing.T) {
+	if d, err := newRecordDecoder(&kafka.Config{}, false, nil); d != nil || err != nil {
+		t.Fatalf("expected no decoder without registry, got %v, %v", d, err)
+	}
+	if d, err := newRecordDecoder(newTestRegistryConfig(t), true, nil); d != nil || err != nil {
+		t.Fatalf("expected no decoder with --no-decode, got %v, %v", d, err)
+	}
+}
+
+func TestRecordEncoderRequiresRegistry(t *testing.T) {
+	ctx := context.Background()
+	if _, err := newRecordEncoder(ctx, &kafka.Config{}, "", "", "", "orders-value", "", ""); !errors.Is(err, kafka.ErrSchemaRegistryNotConfigured) {
+		t.Fatalf("expected ErrSchemaRegistryNotConfigured, got %v", err)
+	}
+
+	encoder, err := newRecordEncoder(ctx, &kafka.Config{}, "", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := 3
+	err = encoder.Encode(ctx, MessageEnvelope{ValueSchemaID: &id}, &kgo.Record{Value: []byte(`{}`)})
+	if err == nil || !strings.Contains(err.Error(), "ValueSchemaID") {
+		t.Fatalf("expected a ValueSchemaID error, got %v", err)
+	}
+}
+
+func TestRecordEncoderMessageType(t *testing.T) {
+	subject := &kafka.Schema{ID: 3}
+	tests := []struct {
+		name     string
+		flag     string
+		subject  *kafka.Schema
+		envelope string
+		want     string
+	}{
+		{"flag", "shop.Refund", nil, "shop.Order", "shop.Refund"},
+		{"envelope", "", nil, "shop.Order", "shop.Order"},
+		{"subject schema ignores the envelope", "", subject, "shop.Order", ""},
+		{"flag with subject schema", "shop.Refund", subject, "", "shop.Refund"},
+	}
+	for _, tt := range tests {
+		if got := (&recordEncoder{}).messageType(tt.flag, tt.subject, tt.envelope); got != tt.want {
+			t.Errorf("%s: messageType() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
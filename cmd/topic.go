@@ -1,14 +1,31 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"fmt"
+	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kadm"
 
 	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
 )
 
+var (
+	topicPartitions        int32
+	topicReplicationFactor int16
+	topicConfigs           []string
+	topicDeleteYes         bool
+	addPartitionsCount     int
+	addPartitionsTotal     int
+	alterConfigSet         []string
+	alterConfigDelete      []string
+	alterConfigDryRun      bool
+)
+
 // topicCmd represents the topic command
 var topicCmd = &cobra.Command{
 	Use:   "topic",
@@ -43,9 +60,223 @@ var listCmd = &cobra.Command{
 	},
 }
 
+var createTopicCmd = &cobra.Command{
+	Use:   "create <topic>",
+	Short: "Create a topic",
+	Long: `Create a topic with the given number of partitions, replication factor and configs.
+Partitions and replication factor default to the broker defaults.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		configs, err := parseConfigPairs(topicConfigs)
+		if err != nil {
+			return err
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		resp, err := adminClient.CreateTopic(context.Background(), topicPartitions, topicReplicationFactor, configs, name)
+		if err != nil {
+			return fmt.Errorf("failed to create topic %s: %w", name, err)
+		}
+
+		color.Green("✅ Created topic '%s' (%d partitions, replication factor %d)", name, resp.NumPartitions, resp.ReplicationFactor)
+		return nil
+	},
+}
+
+var deleteTopicCmd = &cobra.Command{
+	Use:   "delete <topic>...",
+	Short: "Delete one or more topics",
+	Long: `Delete one or more topics. All of their messages are permanently lost.
+You are asked for confirmation unless --yes is given.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !topicDeleteYes {
+			color.Yellow("⚠️  This permanently deletes %s and all of their messages", strings.Join(args, ", "))
+			if !confirm("Delete these topics?") {
+				color.Blue("Aborted")
+				return nil
+			}
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		var failed int
+		for _, name := range args {
+			if _, err := adminClient.DeleteTopic(context.Background(), name); err != nil {
+				color.Red("❌ Failed to delete topic '%s': %v", name, err)
+				failed++
+				continue
+			}
+			color.Green("🗑️  Deleted topic '%s'", name)
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to delete %d of %d topics", failed, len(args))
+		}
+		return nil
+	},
+}
+
+var addPartitionsCmd = &cobra.Command{
+	Use:   "add-partitions <topic>",
+	Short: "Add partitions to a topic",
+	Long: `Add partitions to a topic, either a number of new partitions (--count) or up to a total (--total).
+Partitions can't be removed, and keyed messages may map to different partitions afterwards.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if (addPartitionsCount > 0) == (addPartitionsTotal > 0) {
+			return fmt.Errorf("exactly one of --count or --total must be a positive number")
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		if addPartitionsCount > 0 {
+			err = adminClient.AddPartitions(ctx, name, addPartitionsCount)
+		} else {
+			err = adminClient.SetPartitions(ctx, name, addPartitionsTotal)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add partitions to %s: %w", name, err)
+		}
+
+		if addPartitionsCount > 0 {
+			color.Green("✅ Added %d partitions to topic '%s'", addPartitionsCount, name)
+		} else {
+			color.Green("✅ Topic '%s' now has %d partitions", name, addPartitionsTotal)
+		}
+		return nil
+	},
+}
+
+var alterConfigCmd = &cobra.Command{
+	Use:   "alter-config <topic>",
+	Short: "Set or delete topic configs",
+	Long: `Incrementally set (--set key=value) or delete (--delete key) topic configs.
+Deleted configs fall back to the broker default. Use --dry-run to only validate the changes.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		alterations, err := parseAlterConfigs(alterConfigSet, alterConfigDelete)
+		if err != nil {
+			return err
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if err := adminClient.AlterTopicConfig(context.Background(), name, alterations, alterConfigDryRun); err != nil {
+			return fmt.Errorf("failed to alter configs of %s: %w", name, err)
+		}
+
+		for _, a := range alterations {
+			if a.Op == kadm.DeleteConfig {
+				color.Yellow(" - %s (reset to default)", a.Name)
+			} else {
+				color.Yellow(" - %s=%s", a.Name, *a.Value)
+			}
+		}
+		if alterConfigDryRun {
+			color.Green("✅ Config changes for topic '%s' are valid (dry run, nothing applied)", name)
+		} else {
+			color.Green("✅ Updated configs of topic '%s'", name)
+		}
+		return nil
+	},
+}
+
+// parseConfigPairs parses key=value pairs into a config map
+func parseConfigPairs(pairs []string) (map[string]*string, error) {
+	configs := make(map[string]*string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid config %q, expected key=value", pair)
+		}
+		configs[key] = &value
+	}
+	return configs, nil
+}
+
+// parseAlterConfigs builds incremental config alterations from --set and --delete flags
+func parseAlterConfigs(set, del []string) ([]kadm.AlterConfig, error) {
+	if len(set) == 0 && len(del) == 0 {
+		return nil, fmt.Errorf("at least one --set or --delete is required")
+	}
+
+	var alterations []kadm.AlterConfig
+	for _, pair := range set {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --set %q, expected key=value", pair)
+		}
+		alterations = append(alterations, kadm.AlterConfig{Op: kadm.SetConfig, Name: key, Value: &value})
+	}
+	for _, key := range del {
+		if key == "" {
+			return nil, fmt.Errorf("invalid empty --delete")
+		}
+		alterations = append(alterations, kadm.AlterConfig{Op: kadm.DeleteConfig, Name: key})
+	}
+	return alterations, nil
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(topicCmd)
 	topicCmd.AddCommand(listCmd)
+	topicCmd.AddCommand(createTopicCmd)
+	topicCmd.AddCommand(deleteTopicCmd)
+	topicCmd.AddCommand(addPartitionsCmd)
+	topicCmd.AddCommand(alterConfigCmd)
+
+	createTopicCmd.Flags().Int32VarP(&topicPartitions, "partitions", "p", -1, "Number of partitions (default: broker default)")
+	createTopicCmd.Flags().Int16VarP(&topicReplicationFactor, "replication-factor", "r", -1, "Replication factor (default: broker default)")
+	createTopicCmd.Flags().StringArrayVarP(&topicConfigs, "config", "c", nil, "Topic config as key=value (repeatable)")
+
+	deleteTopicCmd.Flags().BoolVarP(&topicDeleteYes, "yes", "y", false, "Delete without asking for confirmation")
+
+	addPartitionsCmd.Flags().IntVar(&addPartitionsCount, "count", 0, "Number of partitions to add")
+	addPartitionsCmd.Flags().IntVar(&addPartitionsTotal, "total", 0, "Total number of partitions the topic should have")
+
+	alterConfigCmd.Flags().StringArrayVar(&alterConfigSet, "set", nil, "Config to set as key=value (repeatable)")
+	alterConfigCmd.Flags().StringArrayVar(&alterConfigDelete, "delete", nil, "Config to reset to its default (repeatable)")
+	alterConfigCmd.Flags().BoolVar(&alterConfigDryRun, "dry-run", false, "Only validate the changes")
 
 	// Here you will define your flags and configuration settings.
 
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/VincentBoillotDevalliere/kafka-cli/kafka"
+)
+
+// Output formats of the describe commands
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+var describeOutput string
+
+// topicDescription is the describe output of a topic
+type topicDescription struct {
+	Topic             string
+	ID                string
+	Internal          bool
+	ReplicationFactor int
+	MessageCount      int64
+	Partitions        []partitionDescription
+	Configs           []topicConfig
+}
+
+// partitionDescription holds the replica assignment and offsets of a partition.
+// Messages is estimated from the offsets and ignores compacted or deleted records.
+type partitionDescription struct {
+	Partition       int32
+	Leader          int32
+	LeaderEpoch     int32
+	Replicas        []int32
+	ISR             []int32
+	OfflineReplicas []int32
+	UnderReplicated bool
+	EarliestOffset  int64
+	LatestOffset    int64
+	Messages        int64
+	Error           string `json:",omitempty"`
+}
+
+// topicConfig is a topic config that differs from the broker default
+type topicConfig struct {
+	Name      string
+	Value     string
+	Source    string
+	Sensitive bool `json:",omitempty"`
+}
+
+var describeTopicCmd = &cobra.Command{
+	Use:   "describe <topic>",
+	Short: "Describe a topic's partitions, offsets and configs",
+	Long: `Show each partition's leader, replicas, in-sync replicas, offline replicas,
+earliest and latest offsets and an estimate of its message count, followed by
+the topic configs that differ from the defaults.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if describeOutput != OutputTable && describeOutput != OutputJSON {
+			return fmt.Errorf("unsupported --output %q (expected %s or %s)", describeOutput, OutputTable, OutputJSON)
+		}
+
+		cfg := kafka.LoadConfig()
+		client, adminClient, err := cfg.NewAdminClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		ctx := context.Background()
+		topics, err := adminClient.ListTopics(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get topic details: %w", err)
+		}
+		detail, exists := topics[name]
+		if !exists {
+			return fmt.Errorf("topic %s does not exist", name)
+		}
+		if detail.Err != nil {
+			return fmt.Errorf("failed to get topic details: %w", detail.Err)
+		}
+
+		earliest, err := adminClient.ListStartOffsets(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get earliest offsets: %w", err)
+		}
+		latest, err := adminClient.ListEndOffsets(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get latest offsets: %w", err)
+		}
+
+		configs, err := adminClient.DescribeTopicConfigs(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get topic configs: %w", err)
+		}
+		resourceConfig, err := configs.On(name, nil)
+		if err == nil {
+			err = resourceConfig.Err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get topic configs: %w", err)
+		}
+
+		desc := buildTopicDescription(detail, earliest, latest, resourceConfig.Configs)
+		if describeOutput == OutputJSON {
+			return writeJSON(desc)
+		}
+		printTopicDescription(desc)
+		return nil
+	},
+}
+
+// buildTopicDescription combines topic metadata, offsets and configs.
+// Only configs that are not broker defaults are kept.
+func buildTopicDescription(detail kadm.TopicDetail, earliest, latest kadm.ListedOffsets, configs []kadm.Config) topicDescription {
+	desc := topicDescription{
+		Topic:             detail.Topic,
+		ID:                detail.ID.String(),
+		Internal:          detail.IsInternal,
+		ReplicationFactor: detail.Partitions.NumReplicas(),
+		Partitions:        []partitionDescription{},
+	}
+
+	for _, p := range detail.Partitions.Sorted() {
+		pd := partitionDescription{
+			Partition:       p.Partition,
+			Leader:          p.Leader,
+			LeaderEpoch:     p.LeaderEpoch,
+			Replicas:        nonNilInt32s(p.Replicas),
+			ISR:             nonNilInt32s(p.ISR),
+			OfflineReplicas: nonNilInt32s(p.OfflineReplicas),
+			UnderReplicated: len(p.ISR) < len(p.Replicas),
+			EarliestOffset:  -1,
+			LatestOffset:    -1,
+		}
+		if p.Err != nil {
+			pd.Error = p.Err.Error()
+		}
+
+		start, startOK := lookupOffset(earliest, detail.Topic, p.Partition)
+		end, endOK := lookupOffset(latest, detail.Topic, p.Partition)
+		if startOK {
+			pd.EarliestOffset = start
+		}
+		if endOK {
+			pd.LatestOffset = end
+		}
+		if startOK && endOK && end > start {
+			pd.Messages = end - start
+		}
+		desc.MessageCount += pd.Messages
+		desc.Partitions = append(desc.Partitions, pd)
+	}
+
+	// Broker level configs apply to the topic without being set on it
+	desc.Configs = buildConfigs(configs, func(c kadm.Config) bool {
+		return c.Source == kmsg.ConfigSourceDynamicTopicConfig
+	})
+	return desc
+}
+
+// buildConfigs converts the configs kept by keep, sorted by name
+func buildConfigs(configs []kadm.Config, keep func(kadm.Config) bool) []topicConfig {
+	result := []topicConfig{}
+	for _, c := range configs {
+		if !keep(c) {
+			continue
+		}
+		result = append(result, topicConfig{
+			Name:      c.Key,
+			Value:     c.MaybeValue(),
+			Source:    c.Source.String(),
+			Sensitive: c.Sensitive,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func printTopicDescription(desc topicDescription) {
+	color.Cyan("📊 Topic: %s", desc.Topic)
+	fmt.Printf("ID: %s\n", desc.ID)
+	if desc.Internal {
+		fmt.Println("Internal: true")
+	}
+	fmt.Printf("Partitions: %d, replication factor: %d, ~%d messages\n\n",
+		len(desc.Partitions), desc.ReplicationFactor, desc.MessageCount)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PARTITION\tLEADER\tREPLICAS\tISR\tOFFLINE\tEARLIEST\tLATEST\tMESSAGES\t")
+	for _, p := range desc.Partitions {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			p.Partition, leaderName(p.Leader), joinInt32s(p.Replicas), joinInt32s(p.ISR),
+			joinInt32s(p.OfflineReplicas), offsetName(p.EarliestOffset), offsetName(p.LatestOffset),
+			p.Messages, partitionWarning(p))
+	}
+	tw.Flush()
+
+	fmt.Println()
+	if len(desc.Configs) == 0 {
+		color.Blue("Configs: all defaults")
+		return
+	}
+	color.Blue("Configs:")
+	printConfigs(desc.Configs)
+}
+
+// printConfigs prints configs with their values and sources, hiding sensitive values
+func printConfigs(configs []topicConfig) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, c := range configs {
+		value := c.Value
+		if c.Sensitive {
+			value = "(sensitive)"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t(%s)\n", c.Name, value, c.Source)
+	}
+	tw.Flush()
+}
+
+// partitionWarning flags partitions that need attention
+func partitionWarning(p partitionDescription) string {
+	var warnings []string
+	if p.Error != "" {
+		warnings = append(warnings, p.Error)
+	}
+	if p.Leader < 0 {
+		warnings = append(warnings, "no leader")
+	}
+	if p.UnderReplicated {
+		warnings = append(warnings, "under-replicated")
+	}
+	if len(warnings) == 0 {
+		return ""
+	}
+	return color.RedString("⚠️  %s", strings.Join(warnings, ", "))
+}
+
+func leaderName(leader int32) string {
+	if leader < 0 {
+		return "none"
+	}
+	return fmt.Sprint(leader)
+}
+
+func offsetName(offset int64) string {
+	if offset < 0 {
+		return "?"
+	}
+	return fmt.Sprint(offset)
+}
+
+func joinInt32s(values []int32) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+// nonNilInt32s keeps empty lists as [] rather than null in JSON output
+func nonNilInt32s(values []int32) []int32 {
+	if values == nil {
+		return []int32{}
+	}
+	return values
+}
+
+func init() {
+	topicCmd.AddCommand(describeTopicCmd)
+
+	describeTopicCmd.Flags().StringVarP(&describeOutput, "output", "o", OutputTable, "Output format: table or json")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestBuildTopicDescription(t *testing.T) {
+	detail := kadm.TopicDetail{
+		Topic: "orders",
+		Partitions: kadm.PartitionDetails{
+			1: {Topic: "orders", Partition: 1, Leader: 2, Replicas: []int32{2, 3}, ISR: []int32{2}},
+			0: {Topic: "orders", Partition: 0, Leader: 1, Replicas: []int32{1, 2}, ISR: []int32{1, 2}},
+		},
+	}
+	earliest := kadm.ListedOffsets{"orders": {
+		0: {Topic: "orders", Partition: 0, Offset: 10},
+		1: {Topic: "orders", Partition: 1, Offset: 0},
+	}}
+	latest := kadm.ListedOffsets{"orders": {
+		0: {Topic: "orders", Partition: 0, Offset: 110},
+	}}
+	retention := "3600000"
+	defaultPolicy := "delete"
+	segmentBytes := "536870912"
+	configs := []kadm.Config{
+		{Key: "retention.ms", Value: &retention, Source: kmsg.ConfigSourceDynamicTopicConfig},
+		{Key: "cleanup.policy", Value: &defaultPolicy, Source: kmsg.ConfigSourceDefaultConfig},
+		{Key: "segment.bytes", Value: &segmentBytes, Source: kmsg.ConfigSourceStaticBrokerConfig},
+	}
+
+	desc := buildTopicDescription(detail, earliest, latest, configs)
+
+	if desc.ReplicationFactor != 2 {
+		t.Fatalf("expected replication factor 2, got %d", desc.ReplicationFactor)
+	}
+	if len(desc.Partitions) != 2 || desc.Partitions[0].Partition != 0 || desc.Partitions[1].Partition != 1 {
+		t.Fatalf("expected partitions sorted by number, got %+v", desc.Partitions)
+	}
+	if p := desc.Partitions[0]; p.Messages != 100 || p.UnderReplicated {
+		t.Fatalf("unexpected partition 0: %+v", p)
+	}
+	if p := desc.Partitions[1]; p.LatestOffset != -1 || p.Messages != 0 || !p.UnderReplicated {
+		t.Fatalf("unexpected partition 1: %+v", p)
+	}
+	if desc.MessageCount != 100 {
+		t.Fatalf("expected 100 messages, got %d", desc.MessageCount)
+	}
+	if len(desc.Configs) != 1 || desc.Configs[0].Name != "retention.ms" || desc.Configs[0].Source != "DYNAMIC_TOPIC_CONFIG" {
+		t.Fatalf("expected only the topic config, got %+v", desc.Configs)
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestParseConfigPairs(t *testing.T) {
+	configs, err := parseConfigPairs([]string{"retention.ms=1000", "cleanup.policy=compact", "empty="})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(configs) != 3 {
+		t.Fatalf("expected 3 configs, got %d", len(configs))
+	}
+	if v := configs["retention.ms"]; v == nil || *v != "1000" {
+		t.Fatalf("expected retention.ms=1000, got %v", v)
+	}
+	if v := configs["empty"]; v == nil || *v != "" {
+		t.Fatalf("expected empty value, got %v", v)
+	}
+
+	for _, bad := range []string{"retention.ms", "=1000"} {
+		if _, err := parseConfigPairs([]string{bad}); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestParseAlterConfigs(t *testing.T) {
+	alterations, err := parseAlterConfigs([]string{"retention.ms=1000"}, []string{"cleanup.policy"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(alterations) != 2 {
+		t.Fatalf("expected 2 alterations, got %d", len(alterations))
+	}
+	if a := alterations[0]; a.Op != kadm.SetConfig || a.Name != "retention.ms" || *a.Value != "1000" {
+		t.Fatalf("unexpected set alteration: %+v", a)
+	}
+	if a := alterations[1]; a.Op != kadm.DeleteConfig || a.Name != "cleanup.policy" || a.Value != nil {
+		t.Fatalf("unexpected delete alteration: %+v", a)
+	}
+
+	if _, err := parseAlterConfigs(nil, nil); err == nil {
+		t.Fatalf("expected error without alterations")
+	}
+	if _, err := parseAlterConfigs([]string{"retention.ms"}, nil); err == nil {
+		t.Fatalf("expected error for missing value")
+	}
+}
@@ -0,0 +1,165 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+// CreateTopic creates a single topic. A partitions or replicationFactor of -1
+// uses the broker defaults.
+func (ac *AdminClient) CreateTopic(ctx context.Context, partitions int32, replicationFactor int16, configs map[string]*string, topic string) (kadm.CreateTopicResponse, error) {
+	resp, err := ac.Client.CreateTopic(ctx, partitions, replicationFactor, configs, topic)
+	if err != nil {
+		return resp, withErrMessage(err, resp.ErrMessage)
+	}
+	return resp, nil
+}
+
+// DeleteTopic deletes a single topic
+func (ac *AdminClient) DeleteTopic(ctx context.Context, topic string) (kadm.DeleteTopicResponse, error) {
+	resp, err := ac.Client.DeleteTopic(ctx, topic)
+	if err != nil {
+		return resp, withErrMessage(err, resp.ErrMessage)
+	}
+	return resp, nil
+}
+
+// AddPartitions adds count partitions to topic
+func (ac *AdminClient) AddPartitions(ctx context.Context, topic string, count int) error {
+	resps, err := ac.Client.CreatePartitions(ctx, count, topic)
+	return createPartitionsError(resps, err, topic)
+}
+
+// SetPartitions grows topic to a total of total partitions
+func (ac *AdminClient) SetPartitions(ctx context.Context, topic string, total int) error {
+	resps, err := ac.Client.UpdatePartitions(ctx, total, topic)
+	return createPartitionsError(resps, err, topic)
+}
+
+// AlterTopicConfig incrementally sets or deletes configs of topic. With
+// validateOnly, the broker only checks the changes without applying them.
+func (ac *AdminClient) AlterTopicConfig(ctx context.Context, topic string, configs []kadm.AlterConfig, validateOnly bool) error {
+	alter := ac.Client.AlterTopicConfigs
+	if validateOnly {
+		alter = ac.Client.ValidateAlterTopicConfigs
+	}
+
+	resps, err := alter(ctx, configs, topic)
+	if err != nil {
+		return err
+	}
+	resp, err := resps.On(topic, nil)
+	if err != nil {
+		return err
+	}
+	return withErrMessage(resp.Err, resp.ErrMessage)
+}
+
+// DescribeBrokerConfig returns the configs of a broker, with their sources
+func (ac *AdminClient) DescribeBrokerConfig(ctx context.Context, broker int32) ([]kadm.Config, error) {
+	configs, err := ac.Client.DescribeBrokerConfigs(ctx, broker)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := configs.On(strconv.Itoa(int(broker)), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Configs, withErrMessage(resp.Err, resp.ErrMessage)
+}
+
+// AlterBrokerConfig incrementally sets or deletes dynamic configs of broker.
+// With validateOnly, the broker only checks the changes without applying them.
+func (ac *AdminClient) AlterBrokerConfig(ctx context.Context, broker int32, configs []kadm.AlterConfig, validateOnly bool) error {
+	alter := ac.Client.AlterBrokerConfigs
+	if validateOnly {
+		alter = ac.Client.ValidateAlterBrokerConfigs
+	}
+
+	resps, err := alter(ctx, configs, broker)
+	if err != nil {
+		return err
+	}
+	resp, err := resps.On(strconv.Itoa(int(broker)), nil)
+	if err != nil {
+		return err
+	}
+	return withErrMessage(resp.Err, resp.ErrMessage)
+}
+
+// Broker returns the metadata of a broker, or an error listing the brokers
+// of the cluster when there is no such broker
+func (ac *AdminClient) Broker(ctx context.Context, broker int32) (kadm.BrokerDetail, error) {
+	meta, err := ac.Client.BrokerMetadata(ctx)
+	if err != nil {
+		return kadm.BrokerDetail{}, err
+	}
+	for _, b := range meta.Brokers {
+		if b.NodeID == broker {
+			return b, nil
+		}
+	}
+	return kadm.BrokerDetail{}, fmt.Errorf("broker %d is not in the cluster (brokers: %v)", broker, meta.Brokers.NodeIDs())
+}
+
+// DescribeGroup describes a single consumer group
+func (ac *AdminClient) DescribeGroup(ctx context.Context, group string) (kadm.DescribedGroup, error) {
+	groups, err := ac.Client.DescribeGroups(ctx, group)
+	if err != nil {
+		return kadm.DescribedGroup{}, err
+	}
+	described, err := groups.On(group, nil)
+	if err != nil {
+		return described, err
+	}
+	return described, described.Err
+}
+
+// GroupLag returns the lag of group on every partition it is assigned or has
+// committed offsets for. Partitions without a commit count from the log start.
+func (ac *AdminClient) GroupLag(ctx context.Context, group kadm.DescribedGroup) (kadm.GroupLag, error) {
+	commits, err := ac.Client.FetchOffsets(ctx, group.Group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+
+	partitions := group.AssignedPartitions()
+	partitions.MergeTopics(group.JoinTopics())
+	partitions.Merge(commits.Partitions())
+	topics := partitions.Topics()
+	if len(topics) == 0 {
+		return kadm.GroupLag{}, nil
+	}
+
+	start, err := ac.Client.ListStartOffsets(ctx, topics...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earliest offsets: %w", err)
+	}
+	end, err := ac.Client.ListEndOffsets(ctx, topics...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest offsets: %w", err)
+	}
+	return kadm.CalculateGroupLagWithStartOffsets(group, commits, start, end), nil
+}
+
+func createPartitionsError(resps kadm.CreatePartitionsResponses, err error, topic string) error {
+	if err != nil {
+		return err
+	}
+	resp, err := resps.On(topic, nil)
+	if err != nil {
+		return err
+	}
+	return withErrMessage(resp.Err, resp.ErrMessage)
+}
+
+// withErrMessage appends the broker provided error message, if any, to err
+func withErrMessage(err error, msg string) error {
+	if err == nil || msg == "" {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, msg)
+}
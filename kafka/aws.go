@@ -0,0 +1,199 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+)
+
+// Bounds of KAFKA_AWS_ROLE_DURATION, as accepted by STS AssumeRole
+const (
+	minAssumeRoleDuration = 15 * time.Minute
+	maxAssumeRoleDuration = 12 * time.Hour
+)
+
+// DefaultAWSSessionName is the session name of assumed roles, visible in CloudTrail
+const DefaultAWSSessionName = "kafka-cli"
+
+// awsRole is a role assumed on top of the base AWS credentials
+type awsRole struct {
+	ARN         string
+	ExternalID  string
+	SessionName string
+	Duration    time.Duration
+}
+
+// webIdentity is a web identity token file exchanged for role credentials,
+// as mounted in pods by IAM Roles for Service Accounts (IRSA)
+type webIdentity struct {
+	TokenFile string
+	RoleARN   string
+}
+
+// loadAWSSettings reads the AWS credential settings into cfg from the environment or the active profile
+func loadAWSSettings(cfg *Config) error {
+	cfg.AWSProfile = strings.TrimSpace(getSetting("AWS_PROFILE"))
+
+	if tokenFile := expandHome(strings.TrimSpace(getSetting("AWS_WEB_IDENTITY_TOKEN_FILE"))); tokenFile != "" {
+		roleARN := strings.TrimSpace(getSetting("AWS_ROLE_ARN"))
+		if roleARN == "" {
+			return fmt.Errorf("AWS_ROLE_ARN is required with AWS_WEB_IDENTITY_TOKEN_FILE")
+		}
+		if err := validateRoleARN(roleARN); err != nil {
+			return fmt.Errorf("invalid AWS_ROLE_ARN: %w", err)
+		}
+		if _, err := os.Stat(tokenFile); err != nil {
+			return fmt.Errorf("AWS_WEB_IDENTITY_TOKEN_FILE %q: %w (is the service account annotated with eks.amazonaws.com/role-arn?)", tokenFile, err)
+		}
+		cfg.awsWebIdentity = &webIdentity{TokenFile: tokenFile, RoleARN: roleARN}
+	}
+
+	roleARN := strings.TrimSpace(getSetting("KAFKA_AWS_ROLE_ARN"))
+	if roleARN == "" {
+		return nil
+	}
+	if err := validateRoleARN(roleARN); err != nil {
+		return fmt.Errorf("invalid KAFKA_AWS_ROLE_ARN: %w", err)
+	}
+	role := &awsRole{
+		ARN:         roleARN,
+		ExternalID:  getSetting("KAFKA_AWS_EXTERNAL_ID"),
+		SessionName: strings.TrimSpace(getSetting("KAFKA_AWS_ROLE_SESSION_NAME")),
+	}
+	if role.SessionName == "" {
+		role.SessionName = DefaultAWSSessionName
+	}
+	if d := strings.TrimSpace(getSetting("KAFKA_AWS_ROLE_DURATION")); d != "" {
+		duration, err := time.ParseDuration(d)
+		if err != nil {
+			return fmt.Errorf("invalid KAFKA_AWS_ROLE_DURATION %q: %w", d, err)
+		}
+		if duration < minAssumeRoleDuration || duration > maxAssumeRoleDuration {
+			return fmt.Errorf("invalid KAFKA_AWS_ROLE_DURATION %s: expected between %s and %s", duration, minAssumeRoleDuration, maxAssumeRoleDuration)
+		}
+		role.Duration = duration
+	}
+	cfg.AWSRoleARN = role.ARN
+	cfg.awsRole = role
+	return nil
+}
+
+// loadAWSConfig loads the AWS configuration for MSK IAM authentication: the
+// default credential chain or the named profile, then the web identity role,
+// then the role to assume. Assumed credentials are cached until shortly
+// before they expire.
+func (c *Config) loadAWSConfig(ctx context.Context) (*awssdk.Config, error) {
+	opts := []func(*config.LoadOptions) error{config.WithRegion(c.AWSRegion)}
+	if c.AWSProfile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(c.AWSProfile))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		if c.AWSProfile != "" {
+			return nil, fmt.Errorf("failed to load AWS profile %q (check ~/.aws/config): %w", c.AWSProfile, err)
+		}
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	if c.awsWebIdentity != nil {
+		provider := stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(awsCfg), c.awsWebIdentity.RoleARN,
+			stscreds.IdentityTokenFile(c.awsWebIdentity.TokenFile),
+			func(o *stscreds.WebIdentityRoleOptions) {
+				o.RoleSessionName = DefaultAWSSessionName
+			})
+		awsCfg.Credentials = awssdk.NewCredentialsCache(provider)
+	}
+
+	if c.awsRole != nil {
+		// The STS client signs AssumeRole with the credentials loaded so far
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsCfg), c.awsRole.ARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = c.awsRole.SessionName
+			if c.awsRole.Duration > 0 {
+				o.Duration = c.awsRole.Duration
+			}
+			if c.awsRole.ExternalID != "" {
+				o.ExternalID = awssdk.String(c.awsRole.ExternalID)
+			}
+		})
+		awsCfg.Credentials = awssdk.NewCredentialsCache(provider, func(o *awssdk.CredentialsCacheOptions) {
+			o.ExpiryWindow = time.Minute
+		})
+	}
+	return &awsCfg, nil
+}
+
+// awsCredentialsSource describes where the AWS credentials come from
+func (c *Config) awsCredentialsSource() string {
+	var parts []string
+	switch {
+	case c.awsWebIdentity != nil:
+		parts = append(parts, fmt.Sprintf("web identity %s (token %s)", c.awsWebIdentity.RoleARN, c.awsWebIdentity.TokenFile))
+	case c.AWSProfile != "":
+		parts = append(parts, fmt.Sprintf("AWS profile %q", c.AWSProfile))
+	default:
+		parts = append(parts, "the default AWS credential chain")
+	}
+	if c.awsRole != nil {
+		parts = append(parts, "assuming "+c.awsRole.ARN)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// awsCredentialsError explains a failure to retrieve AWS credentials for MSK IAM
+func (c *Config) awsCredentialsError(err error) error {
+	msg := "failed to retrieve AWS credentials from " + c.awsCredentialsSource()
+	if hint := awsCredentialsHint(err, c.awsRole); hint != "" {
+		return fmt.Errorf("%s: %w (%s)", msg, err, hint)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// awsCredentialsHint suggests a fix for common credential errors
+func awsCredentialsHint(err error, role *awsRole) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "AccessDenied":
+			if role != nil {
+				hint := "check that the caller may call sts:AssumeRole on " + role.ARN + " and that the role trust policy allows it"
+				if role.ExternalID != "" {
+					hint += " with this external ID"
+				}
+				return hint
+			}
+			return "check the IAM permissions of the caller"
+		case "ExpiredToken", "ExpiredTokenException":
+			return "the session credentials expired, refresh them (e.g. aws sso login) and retry"
+		case "InvalidIdentityToken", "IDPRejectedClaim":
+			return "the web identity token was rejected, check the service account role annotation and the role trust policy"
+		case "InvalidClientTokenId", "SignatureDoesNotMatch":
+			return "the access key is invalid or its secret is wrong"
+		}
+	}
+
+	text := err.Error()
+	switch {
+	case strings.Contains(text, "no EC2 IMDS role found"), strings.Contains(text, "failed to refresh cached credentials"):
+		return "no AWS credentials found: set --aws-profile or AWS_PROFILE, AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY, or run with an IRSA service account"
+	case strings.Contains(text, "SSO"), strings.Contains(text, "sso"):
+		return "the AWS SSO session may have expired, run aws sso login"
+	}
+	return ""
+}
+
+// validateRoleARN checks that arn looks like an IAM role ARN
+func validateRoleARN(arn string) error {
+	if !strings.HasPrefix(arn, "arn:") || !strings.Contains(arn, ":role/") {
+		return fmt.Errorf("%q is not an IAM role ARN (arn:aws:iam::<account>:role/<name>)", arn)
+	}
+	return nil
+}
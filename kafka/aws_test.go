@@ -0,0 +1,211 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// newTestSTS starts an STS endpoint answering AssumeRole for roleARN with
+// credentials valid for an hour, and returns a counter of the calls
+func newTestSTS(t *testing.T, roleARN string) *int32 {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "text/xml")
+		if r.FormValue("Action") != "AssumeRole" || r.FormValue("RoleArn") != roleARN ||
+			r.FormValue("ExternalId") != "ext-42" || r.FormValue("RoleSessionName") != "ci" || r.FormValue("DurationSeconds") != "1800" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>not authorized</Message></Error><RequestId>1</RequestId></ErrorResponse>`)
+			return
+		}
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult>
+<Credentials><AccessKeyId>ASIAASSUMED</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken><Expiration>%s</Expiration></Credentials>
+<AssumedRoleUser><Arn>%s/ci</Arn><AssumedRoleId>AROA:ci</AssumedRoleId></AssumedRoleUser>
+</AssumeRoleResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></AssumeRoleResponse>`,
+			time.Now().Add(time.Hour).UTC().Format(time.RFC3339), roleARN)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("AWS_ENDPOINT_URL_STS", srv.URL)
+	return &calls
+}
+
+// setAWSEnv isolates the AWS SDK from the host configuration, with static
+// credentials in the named profile "dev"
+func setAWSEnv(t *testing.T) {
+	t.Helper()
+	clearSettingsEnv(t)
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials")
+	if err := os.WriteFile(credentials, []byte("[dev]\naws_access_key_id = AKIADEV\naws_secret_access_key = dev-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	for _, key := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_DEFAULT_PROFILE"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("KAFKA_TLS_ENABLED", "false")
+	t.Setenv("KAFKA_USE_AWS_IAM", "true")
+	t.Setenv("AWS_REGION", "eu-west-1")
+}
+
+func TestAWSAssumeRoleCachesCredentials(t *testing.T) {
+	setAWSEnv(t)
+	roleARN := "arn:aws:iam::123456789012:role/kafka-admin"
+	calls := newTestSTS(t, roleARN)
+	t.Setenv("AWS_PROFILE", "dev")
+	t.Setenv("KAFKA_AWS_ROLE_ARN", roleARN)
+	t.Setenv("KAFKA_AWS_EXTERNAL_ID", "ext-42")
+	t.Setenv("KAFKA_AWS_ROLE_SESSION_NAME", "ci")
+	t.Setenv("KAFKA_AWS_ROLE_DURATION", "30m")
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AWSProfile != "dev" || cfg.AWSRoleARN != roleARN {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	for i := 0; i < 3; i++ {
+		creds, err := cfg.awsConfig.Credentials.Retrieve(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds.AccessKeyID != "ASIAASSUMED" || creds.SessionToken != "session" {
+			t.Fatalf("unexpected credentials %s", creds.AccessKeyID)
+		}
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Fatalf("%d AssumeRole calls, want 1", n)
+	}
+}
+
+func TestAWSAssumeRoleAccessDenied(t *testing.T) {
+	setAWSEnv(t)
+	newTestSTS(t, "arn:aws:iam::123456789012:role/kafka-admin")
+	t.Setenv("AWS_PROFILE", "dev")
+	t.Setenv("KAFKA_AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/other")
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cfg.awsConfig.Credentials.Retrieve(context.Background())
+	if err == nil {
+		t.Fatalf("expected AccessDenied")
+	}
+	err = cfg.awsCredentialsError(err)
+	for _, want := range []string{`AWS profile "dev"`, "assuming arn:aws:iam::123456789012:role/other", "sts:AssumeRole"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestAWSUnknownProfile(t *testing.T) {
+	setAWSEnv(t)
+	t.Setenv("AWS_PROFILE", "missing")
+	if _, err := NewConfig(); err == nil || !strings.Contains(err.Error(), `AWS profile "missing"`) {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+}
+
+func TestAWSProfileOverride(t *testing.T) {
+	setAWSEnv(t)
+	t.Setenv("AWS_PROFILE", "missing")
+	OverrideSetting("AWS_PROFILE", "dev")
+	t.Cleanup(func() { OverrideSetting("AWS_PROFILE", "") })
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AWSProfile != "dev" {
+		t.Fatalf("AWSProfile = %q, want the override", cfg.AWSProfile)
+	}
+}
+
+func TestAWSSettingsErrors(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("jwt"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	role := "arn:aws:iam::123456789012:role/kafka-ui"
+	tests := map[string]map[string]string{
+		"invalid role ARN":         {"KAFKA_AWS_ROLE_ARN": "kafka-admin"},
+		"invalid duration":         {"KAFKA_AWS_ROLE_ARN": role, "KAFKA_AWS_ROLE_DURATION": "1h30"},
+		"duration too short":       {"KAFKA_AWS_ROLE_ARN": role, "KAFKA_AWS_ROLE_DURATION": "5m"},
+		"duration too long":        {"KAFKA_AWS_ROLE_ARN": role, "KAFKA_AWS_ROLE_DURATION": "24h"},
+		"token without role":       {"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile},
+		"missing token file":       {"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile + ".missing", "AWS_ROLE_ARN": role},
+		"invalid web identity ARN": {"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile, "AWS_ROLE_ARN": "kafka-ui"},
+	}
+	for name, env := range tests {
+		t.Run(name, func(t *testing.T) {
+			clearSettingsEnv(t)
+			for k, v := range env {
+				t.Setenv(k, v)
+			}
+			if err := loadAWSSettings(&Config{}); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestAWSWebIdentity(t *testing.T) {
+	clearSettingsEnv(t)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("jwt"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/kafka-ui")
+
+	cfg := &Config{}
+	if err := loadAWSSettings(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.awsWebIdentity == nil || cfg.awsWebIdentity.TokenFile != tokenFile || cfg.awsRole != nil {
+		t.Fatalf("unexpected settings %+v", cfg)
+	}
+	if source := cfg.awsCredentialsSource(); !strings.Contains(source, "web identity arn:aws:iam::123456789012:role/kafka-ui") {
+		t.Fatalf("unexpected source %q", source)
+	}
+}
+
+func TestAWSCredentialsHint(t *testing.T) {
+	role := &awsRole{ARN: "arn:aws:iam::123456789012:role/kafka-admin", ExternalID: "ext"}
+	tests := []struct {
+		err  error
+		role *awsRole
+		want string
+	}{
+		{&smithy.GenericAPIError{Code: "AccessDenied"}, role, "sts:AssumeRole on arn:aws:iam::123456789012:role/kafka-admin"},
+		{&smithy.GenericAPIError{Code: "AccessDenied"}, nil, "IAM permissions"},
+		{&smithy.GenericAPIError{Code: "ExpiredToken"}, nil, "expired"},
+		{&smithy.GenericAPIError{Code: "InvalidIdentityToken"}, nil, "service account"},
+		{errors.New("failed to refresh cached credentials, no EC2 IMDS role found"), nil, "--aws-profile"},
+		{errors.New("connection refused"), nil, ""},
+	}
+	for _, tt := range tests {
+		hint := awsCredentialsHint(tt.err, tt.role)
+		if (tt.want == "" && hint != "") || !strings.Contains(hint, tt.want) {
+			t.Errorf("awsCredentialsHint(%v) = %q, want %q", tt.err, hint, tt.want)
+		}
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"time"
 
 	awssdk "github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/sasl/aws"
@@ -19,12 +18,36 @@ import (
 
 // Config holds the Kafka configuration
 type Config struct {
-	Brokers    []string
-	UseAWSIAM  bool
-	AWSRegion  string
-	TLSEnabled bool
-	awsConfig  *awssdk.Config
-	tlsConfig  *tls.Config
+	Profile     string // name of the profile in use, if any
+	Brokers     []string
+	UseAWSIAM   bool
+	AWSRegion   string
+	AWSProfile  string
+	AWSRoleARN  string
+	TLSEnabled  bool
+	Partitioner string
+	awsConfig   *awssdk.Config
+	tlsConfig   *tls.Config
+
+	// AWS credentials layered over the default chain, optional
+	awsRole        *awsRole
+	awsWebIdentity *webIdentity
+
+	// SASL PLAIN, SCRAM or OAUTHBEARER, optional
+	SASLMechanism      string
+	SASLUsername       string
+	OAuthTokenEndpoint string
+	saslPassword       string
+	saslPasswordFile   string
+	oauthTokens        tokenSource
+	oauthExtensions    map[string]string
+
+	// Schema Registry, optional. The credentials may be secret references,
+	// resolved by NewSchemaRegistry.
+	SchemaRegistryURL      string
+	SchemaRegistryUsername string
+	SchemaRegistryPassword string
+	SchemaRegistryToken    string
 }
 
 // LoadConfig is a convenience function that creates a new Kafka configuration
@@ -37,14 +60,18 @@ func LoadConfig() *Config {
 	return cfg
 }
 
-// NewConfig creates a new Kafka configuration from environment variables
+// NewConfig creates a new Kafka configuration from environment variables,
+// falling back to the profile set with UseProfile
 func NewConfig() (*Config, error) {
 	cfg := &Config{
 		TLSEnabled: true, // Default to true for security
 	}
+	if profile := ActiveProfile(); profile != nil {
+		cfg.Profile = profile.Name
+	}
 
 	// Parse broker addresses
-	brokersEnv := os.Getenv("KAFKA_BROKERS")
+	brokersEnv := getSetting("KAFKA_BROKERS")
 	if brokersEnv == "" {
 		brokersEnv = "localhost:9092" // Default for local development
 		cfg.TLSEnabled = false
@@ -56,15 +83,26 @@ func NewConfig() (*Config, error) {
 		cfg.TLSEnabled = tlsEnabled
 	}
 
+	// Producer partitioner, validated early so typos fail before producing
+	cfg.Partitioner = strings.TrimSpace(getSetting("KAFKA_PARTITIONER"))
+	if _, err := ParsePartitioner(cfg.Partitioner); err != nil {
+		return nil, fmt.Errorf("invalid KAFKA_PARTITIONER: %w", err)
+	}
+
 	// Check if AWS IAM is enabled - auto-detect MSK or explicit setting
 	if useIAM, ok := lookupEnvBool("KAFKA_USE_AWS_IAM"); ok {
 		cfg.UseAWSIAM = useIAM
 	}
 
+	// SASL PLAIN/SCRAM/OAUTHBEARER, SCRAM is also used by MSK clusters
+	if err := loadSASLConfig(cfg); err != nil {
+		return nil, err
+	}
+
 	// Auto-detect AWS MSK based on broker URLs
-	if !cfg.UseAWSIAM {
+	if !cfg.UseAWSIAM && cfg.SASLMechanism == "" {
 		for _, broker := range cfg.Brokers {
-			if strings.Contains(broker, ".kafka.") && strings.Contains(broker, ".amazonaws.com") {
+			if isMSKBroker(broker) {
 				cfg.UseAWSIAM = true
 				break
 			}
@@ -73,20 +111,28 @@ func NewConfig() (*Config, error) {
 
 	if cfg.UseAWSIAM {
 		// Get AWS region
-		cfg.AWSRegion = os.Getenv("AWS_REGION")
+		cfg.AWSRegion = getSetting("AWS_REGION")
 		if cfg.AWSRegion == "" {
-			return nil, fmt.Errorf("AWS_REGION environment variable is required when using AWS MSK")
+			return nil, fmt.Errorf("AWS_REGION (or aws.region in the profile) is required when using AWS MSK")
 		}
 
-		// Load AWS configuration
-		awsCfg, err := config.LoadDefaultConfig(context.Background(),
-			config.WithRegion(cfg.AWSRegion))
+		// Load AWS configuration: profile, web identity and role to assume
+		if err := loadAWSSettings(cfg); err != nil {
+			return nil, err
+		}
+		awsCfg, err := cfg.loadAWSConfig(context.Background())
 		if err != nil {
-			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+			return nil, err
 		}
-		cfg.awsConfig = &awsCfg
+		cfg.awsConfig = awsCfg
 	}
 
+	// Credentials are only resolved by the commands using the registry
+	cfg.SchemaRegistryURL = strings.TrimSpace(getSetting("SCHEMA_REGISTRY_URL"))
+	cfg.SchemaRegistryUsername = getSetting("SCHEMA_REGISTRY_USERNAME")
+	cfg.SchemaRegistryPassword = getSetting("SCHEMA_REGISTRY_PASSWORD")
+	cfg.SchemaRegistryToken = getSetting("SCHEMA_REGISTRY_BEARER_TOKEN")
+
 	if cfg.TLSEnabled {
 		tlsCfg, err := buildTLSConfigFromEnv()
 		if err != nil {
@@ -107,7 +153,7 @@ func buildTLSConfigFromEnv() (*tls.Config, error) {
 		tlsCfg.InsecureSkipVerify = insecureSkipVerify
 	}
 
-	if caFile := strings.TrimSpace(os.Getenv("KAFKA_TLS_CA_FILE")); caFile != "" {
+	if caFile := strings.TrimSpace(getSetting("KAFKA_TLS_CA_FILE")); caFile != "" {
 		data, err := os.ReadFile(caFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read KAFKA_TLS_CA_FILE %q: %w", caFile, err)
@@ -119,11 +165,27 @@ func buildTLSConfigFromEnv() (*tls.Config, error) {
 		tlsCfg.RootCAs = pool
 	}
 
+	// Override the server name verified and sent with SNI, e.g. to reach brokers through a proxy
+	if serverName := strings.TrimSpace(getSetting("KAFKA_TLS_SERVER_NAME")); serverName != "" {
+		tlsCfg.ServerName = serverName
+	}
+
+	// Client certificate for mutual TLS
+	if err := configureClientCertificate(tlsCfg); err != nil {
+		return nil, err
+	}
+
 	return tlsCfg, nil
 }
 
+// isMSKBroker reports whether a broker host or address is an Amazon MSK broker
+func isMSKBroker(broker string) bool {
+	return strings.Contains(broker, ".kafka.") && strings.Contains(broker, ".amazonaws.com")
+}
+
+// lookupEnvBool parses a boolean setting from the environment or the active profile
 func lookupEnvBool(key string) (bool, bool) {
-	val, ok := os.LookupEnv(key)
+	val, ok := lookupSetting(key)
 	if !ok {
 		return false, false
 	}
@@ -138,23 +200,31 @@ func lookupEnvBool(key string) (bool, bool) {
 	}
 }
 
-// CreateProducer creates a new Kafka producer with the configuration
+// CreateProducer creates a new Kafka producer with the configuration.
+// Records are partitioned with the configured partitioner (see ParsePartitioner),
+// except records whose Partition is set, which always go to that partition.
 func (c *Config) CreateProducer(opts ...ProducerOption) (*kgo.Client, error) {
-	options := c.getBaseOptions()
-
-	// Apply producer-specific options
-	for _, opt := range opts {
-		opt(&options)
+	partitioner, err := ParsePartitioner(c.Partitioner)
+	if err != nil {
+		return nil, err
 	}
 
+	options := c.getBaseOptions()
+
 	// Add producer-specific configurations
 	options = append(options,
 		kgo.RequiredAcks(kgo.AllISRAcks()), // Wait for all replicas
 		kgo.ProducerBatchMaxBytes(1000000), // 1MB batches
 		kgo.ProducerBatchCompression(kgo.GzipCompression()),
 		kgo.ProducerLinger(100*time.Millisecond), // Batch for up to 100ms
+		kgo.RecordPartitioner(newExplicitPartitioner(partitioner)),
 	)
 
+	// Apply producer-specific options last so they override the defaults above
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	client, err := kgo.NewClient(options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
@@ -200,6 +270,38 @@ func (c *Config) CreateConsumer(consumerGroup string, topics []string, opts ...C
 	return client, nil
 }
 
+// CreateDirectConsumer creates a consumer that reads topic without a consumer group.
+// Nothing is committed. Every partition, or only the given partitions, starts at offset.
+func (c *Config) CreateDirectConsumer(topic string, partitions []int32, offset kgo.Offset, opts ...ConsumerOption) (*kgo.Client, error) {
+	if topic == "" {
+		return nil, fmt.Errorf("a topic is required")
+	}
+
+	options := c.getBaseOptions()
+	if len(partitions) > 0 {
+		assigned := make(map[int32]kgo.Offset, len(partitions))
+		for _, p := range partitions {
+			assigned[p] = offset
+		}
+		options = append(options, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: assigned}))
+	} else {
+		options = append(options, kgo.ConsumeTopics(topic), kgo.ConsumeResetOffset(offset))
+	}
+	options = append(options,
+		kgo.FetchMaxWait(500*time.Millisecond),
+	)
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client, err := kgo.NewClient(options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	return client, nil
+}
+
 // getBaseOptions returns the base kgo options for both producers and consumers
 func (c *Config) getBaseOptions() []kgo.Opt {
 	options := []kgo.Opt{
@@ -218,7 +320,7 @@ func (c *Config) getBaseOptions() []kgo.Opt {
 		saslMech := aws.ManagedStreamingIAM(func(ctx context.Context) (aws.Auth, error) {
 			creds, err := c.awsConfig.Credentials.Retrieve(ctx)
 			if err != nil {
-				return aws.Auth{}, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+				return aws.Auth{}, c.awsCredentialsError(err)
 			}
 
 			return aws.Auth{
@@ -232,6 +334,11 @@ func (c *Config) getBaseOptions() []kgo.Opt {
 		options = append(options, kgo.SASL(saslMech))
 	}
 
+	// Add SASL PLAIN, SCRAM or OAUTHBEARER, over TLS when enabled
+	if c.SASLMechanism != "" {
+		options = append(options, kgo.SASL(c.saslMechanism()))
+	}
+
 	return options
 }
 
@@ -266,6 +373,21 @@ func WithRequiredAcks(acks kgo.Acks) ProducerOption {
 	}
 }
 
+// WithMaxBufferedRecords limits how many records may be in flight at once;
+// producing blocks until earlier records complete once the limit is reached
+func WithMaxBufferedRecords(n int) ProducerOption {
+	return func(opts *[]kgo.Opt) {
+		*opts = append(*opts, kgo.MaxBufferedRecords(n))
+	}
+}
+
+// WithPartitioner sets the partitioner used for records without an explicit partition
+func WithPartitioner(partitioner kgo.Partitioner) ProducerOption {
+	return func(opts *[]kgo.Opt) {
+		*opts = append(*opts, kgo.RecordPartitioner(newExplicitPartitioner(partitioner)))
+	}
+}
+
 // ConsumerOption is a function type for configuring consumer options
 type ConsumerOption func(*[]kgo.Opt)
 
@@ -313,23 +435,6 @@ func WithFetchMaxWait(maxWait time.Duration) ConsumerOption {
 	}
 }
 
-// TestConnection tests the connection to Kafka brokers
-func (c *Config) TestConnection(ctx context.Context) error {
-	client, err := kgo.NewClient(c.getBaseOptions()...)
-	if err != nil {
-		return fmt.Errorf("failed to create test client: %w", err)
-	}
-	defer client.Close()
-
-	// Try to ping brokers to test connection
-	err = client.Ping(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to connect to Kafka brokers: %w", err)
-	}
-
-	return nil
-}
-
 // GetBrokers returns the configured broker addresses
 func (c *Config) GetBrokers() []string {
 	return c.Brokers
@@ -345,19 +450,54 @@ func (c *Config) GetAWSRegion() string {
 	return c.AWSRegion
 }
 
+// GetSASLMechanism returns the configured SASL mechanism, empty when SASL is disabled
+func (c *Config) GetSASLMechanism() string {
+	return c.SASLMechanism
+}
+
 // IsTLSEnabled returns true if TLS is enabled
 func (c *Config) IsTLSEnabled() bool {
 	return c.TLSEnabled
 }
 
+// NewSchemaRegistry creates a Schema Registry client from the configuration,
+// resolving the secret references of the credentials in use. It returns
+// ErrSchemaRegistryNotConfigured when SchemaRegistryURL is empty.
+func (c *Config) NewSchemaRegistry() (*SchemaRegistry, error) {
+	if c.SchemaRegistryURL == "" {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	var opts []RegistryOption
+	token, err := resolveSecretSetting("SCHEMA_REGISTRY_BEARER_TOKEN", c.SchemaRegistryToken)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case token != "":
+		opts = append(opts, WithRegistryBearerToken(token))
+	case c.SchemaRegistryUsername != "":
+		username, err := resolveSecretSetting("SCHEMA_REGISTRY_USERNAME", c.SchemaRegistryUsername)
+		if err != nil {
+			return nil, err
+		}
+		password, err := resolveSecretSetting("SCHEMA_REGISTRY_PASSWORD", c.SchemaRegistryPassword)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithRegistryBasicAuth(username, password))
+	}
+	return NewSchemaRegistry(c.SchemaRegistryURL, opts...)
+}
+
 // NewConsumerClient creates a new consumer client (for backward compatibility)
 func (c *Config) NewConsumerClient(groupID, topic string) (*kgo.Client, error) {
 	return c.CreateConsumer(groupID, []string{topic})
 }
 
 // NewProducerClient creates a new producer client (for backward compatibility)
-func (c *Config) NewProducerClient(topic string) (*kgo.Client, error) {
-	return c.CreateProducer()
+func (c *Config) NewProducerClient(topic string, opts ...ProducerOption) (*kgo.Client, error) {
+	return c.CreateProducer(opts...)
 }
 
 // AdminClient wraps kadm.Client to provide the expected admin operations
@@ -406,6 +546,8 @@ func (c *Config) NewPartitionConsumerClient(topic string, partition int, offset
 		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{
 			topic: {int32(partition): kgo.NewOffset().At(offset)},
 		}),
+		// Transaction markers are kept so that a range ending on one is seen to end
+		kgo.KeepControlRecords(),
 	)
 
 	client, err := kgo.NewClient(options...)
@@ -0,0 +1,615 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/kversion"
+)
+
+// Diagnostic steps, in the order Diagnose runs them
+const (
+	StepConfig      = "config"
+	StepCredentials = "credentials"
+	StepClientCert  = "client-cert"
+	StepDNS         = "dns"
+	StepTCP         = "tcp"
+	StepTLS         = "tls"
+	StepSASL        = "sasl"
+	StepAPIVersions = "api-versions"
+	StepMetadata    = "metadata"
+)
+
+// CheckStatus is the outcome of a diagnostic step
+type CheckStatus string
+
+// Outcomes of a diagnostic step
+const (
+	CheckOK   CheckStatus = "ok"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+	CheckSkip CheckStatus = "skip"
+)
+
+// certExpiryWarning is how long before expiry a certificate is reported
+const certExpiryWarning = 14 * 24 * time.Hour
+
+// MSK listener ports, private then public, by authentication
+var (
+	mskPlaintextPorts = []string{"9092"}
+	mskTLSPorts       = []string{"9094", "9194"}
+	mskSCRAMPorts     = []string{"9096", "9196"}
+	mskIAMPorts       = []string{"9098", "9198"}
+)
+
+// Check is the result of a diagnostic step, for one broker or the whole cluster
+type Check struct {
+	Broker  string `json:",omitempty"`
+	Step    string
+	Status  CheckStatus
+	Detail  string   `json:",omitempty"`
+	Details []string `json:",omitempty"` // certificate chain, brokers, ...
+	Hint    string   `json:",omitempty"`
+}
+
+// connectionTestTimeout bounds each network step of TestConnection
+const connectionTestTimeout = 10 * time.Second
+
+// TestConnection runs the checks of Diagnose and returns an error when the
+// cluster can't be used: the credentials can't be resolved, or no broker
+// answers the metadata request. The error holds the first failure and its hint.
+func (c *Config) TestConnection(ctx context.Context) error {
+	var failure *Check
+	c.Diagnose(ctx, connectionTestTimeout, func(check Check) {
+		if check.Status == CheckFail && failure == nil {
+			failure = &check
+		}
+		if check.Step == StepMetadata && check.Status == CheckOK {
+			failure = nil
+		}
+	})
+	if failure == nil {
+		return nil
+	}
+	step := failure.Step
+	if failure.Broker != "" {
+		step = failure.Broker + " " + step
+	}
+	if failure.Hint != "" {
+		return fmt.Errorf("failed to connect to Kafka brokers: %s: %s (%s)", step, failure.Detail, failure.Hint)
+	}
+	return fmt.Errorf("failed to connect to Kafka brokers: %s: %s", step, failure.Detail)
+}
+
+// Diagnose checks the connection step by step and calls report after each
+// step: the configuration and credentials, then for each seed broker DNS,
+// TCP, TLS, SASL and ApiVersions, then the cluster metadata. The checks of a
+// broker stop at its first failure. timeout bounds each network step.
+func (c *Config) Diagnose(ctx context.Context, timeout time.Duration, report func(Check)) {
+	c.diagnoseConfig(report)
+	if !c.diagnoseCredentials(ctx, timeout, report) {
+		return
+	}
+	c.diagnoseClientCertificate(report)
+
+	reachable := 0
+	for _, broker := range c.Brokers {
+		if c.diagnoseBroker(ctx, broker, timeout, report) {
+			reachable++
+		}
+	}
+	if reachable == 0 {
+		report(Check{Step: StepMetadata, Status: CheckSkip, Detail: "no broker is reachable"})
+		return
+	}
+	c.diagnoseMetadata(ctx, timeout, report)
+}
+
+// diagnoseConfig reports the settings in use and listener port mismatches
+func (c *Config) diagnoseConfig(report func(Check)) {
+	check := Check{Step: StepConfig, Status: CheckOK, Detail: c.describeConnection()}
+	if c.Profile != "" {
+		check.Detail += ", profile " + c.Profile
+	}
+	for _, broker := range c.Brokers {
+		host, port := splitBroker(broker)
+		if hint := c.portHint(host, port); hint != "" {
+			check.Status = CheckWarn
+			check.Hint = hint
+			break
+		}
+	}
+	report(check)
+}
+
+// describeConnection summarizes the brokers, encryption and authentication
+func (c *Config) describeConnection() string {
+	parts := []string{fmt.Sprintf("%d broker(s)", len(c.Brokers))}
+	if c.TLSEnabled {
+		parts = append(parts, "TLS")
+	} else {
+		parts = append(parts, "plaintext")
+	}
+	switch {
+	case c.UseAWSIAM:
+		parts = append(parts, "MSK IAM in "+c.AWSRegion)
+	case c.SASLMechanism == SASLOAuthBearer:
+		parts = append(parts, "SASL "+c.SASLMechanism)
+	case c.SASLMechanism != "":
+		parts = append(parts, fmt.Sprintf("SASL %s as %s", c.SASLMechanism, c.SASLUsername))
+	default:
+		parts = append(parts, "no SASL")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// portHint flags a broker port that does not match the authentication, for
+// MSK whose listener ports are fixed
+func (c *Config) portHint(host, port string) string {
+	if !isMSKBroker(host) {
+		return ""
+	}
+	switch {
+	case c.UseAWSIAM && !slices.Contains(mskIAMPorts, port):
+		return fmt.Sprintf("MSK IAM authentication listens on port 9098 (9198 with public access), not %s", port)
+	case isSCRAM(c.SASLMechanism) && !slices.Contains(mskSCRAMPorts, port):
+		return fmt.Sprintf("MSK SASL/SCRAM listens on port 9096 (9196 with public access), not %s", port)
+	case !c.TLSEnabled && !slices.Contains(mskPlaintextPorts, port):
+		return fmt.Sprintf("MSK port %s requires TLS: set KAFKA_TLS_ENABLED=true", port)
+	case c.TLSEnabled && slices.Contains(mskPlaintextPorts, port):
+		return "MSK port 9092 is the plaintext listener: use 9094 for TLS, or set KAFKA_TLS_ENABLED=false"
+	case !c.UseAWSIAM && c.SASLMechanism == "" && c.TLSEnabled && !slices.Contains(mskTLSPorts, port):
+		return fmt.Sprintf("MSK port %s requires authentication: set KAFKA_USE_AWS_IAM=true for 9098, or KAFKA_SASL_MECHANISM=SCRAM-SHA-512 for 9096", port)
+	}
+	return ""
+}
+
+// diagnoseCredentials fetches the AWS credentials or OAuth token up front,
+// so that their errors are not mistaken for rejected authentication
+func (c *Config) diagnoseCredentials(ctx context.Context, timeout time.Duration, report func(Check)) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch {
+	case c.UseAWSIAM && c.awsConfig != nil:
+		creds, err := c.awsConfig.Credentials.Retrieve(ctx)
+		if err != nil {
+			report(Check{
+				Step:   StepCredentials,
+				Status: CheckFail,
+				Detail: fmt.Sprintf("failed to retrieve AWS credentials from %s: %v", c.awsCredentialsSource(), err),
+				Hint:   awsCredentialsHint(err, c.awsRole),
+			})
+			return false
+		}
+		check := Check{Step: StepCredentials, Status: CheckOK, Detail: "AWS credentials from " + c.awsCredentialsSource()}
+		if creds.CanExpire {
+			check.Detail += fmt.Sprintf(", expire in %s", time.Until(creds.Expires).Round(time.Second))
+		}
+		report(check)
+	case c.SASLMechanism == SASLOAuthBearer:
+		if _, err := c.oauthTokens.Token(ctx); err != nil {
+			report(Check{
+				Step:   StepCredentials,
+				Status: CheckFail,
+				Detail: err.Error(),
+				Hint:   "check KAFKA_SASL_OAUTH_TOKEN_ENDPOINT and the client credentials with the identity provider",
+			})
+			return false
+		}
+		source := "static token"
+		if c.OAuthTokenEndpoint != "" {
+			source = "token from " + c.OAuthTokenEndpoint
+		}
+		report(Check{Step: StepCredentials, Status: CheckOK, Detail: "OAuth " + source})
+	}
+	return true
+}
+
+// diagnoseClientCertificate reports the client certificate of mutual TLS
+func (c *Config) diagnoseClientCertificate(report func(Check)) {
+	if !c.TLSEnabled || c.tlsConfig == nil {
+		return
+	}
+	var cert *tls.Certificate
+	switch {
+	case c.tlsConfig.GetClientCertificate != nil:
+		cert, _ = c.tlsConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	case len(c.tlsConfig.Certificates) > 0:
+		cert = &c.tlsConfig.Certificates[0]
+	}
+	if cert == nil || cert.Leaf == nil {
+		return
+	}
+
+	check := Check{Step: StepClientCert, Status: CheckOK, Detail: describeCertificate(cert.Leaf)}
+	switch remaining := time.Until(cert.Leaf.NotAfter); {
+	case remaining <= 0:
+		check.Status = CheckFail
+		check.Hint = "the client certificate expired: renew it (KAFKA_TLS_CERT_FILE or KAFKA_TLS_PKCS12_FILE)"
+	case remaining < certExpiryWarning:
+		check.Status = CheckWarn
+		check.Hint = "the client certificate expires soon: renew it"
+	}
+	report(check)
+}
+
+// diagnoseBroker checks one seed broker and reports whether it answered ApiVersions
+func (c *Config) diagnoseBroker(ctx context.Context, broker string, timeout time.Duration, report func(Check)) bool {
+	host, port := splitBroker(broker)
+	addr := net.JoinHostPort(host, port)
+	result := func(step string, status CheckStatus, detail, hint string) {
+		report(Check{Broker: addr, Step: step, Status: status, Detail: detail, Hint: hint})
+	}
+
+	// DNS
+	dnsCtx, cancel := context.WithTimeout(ctx, timeout)
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(dnsCtx, host)
+	cancel()
+	if err != nil {
+		hint := "check the host name in KAFKA_BROKERS and the DNS servers of this network"
+		if isMSKBroker(host) {
+			hint = "use the bootstrap brokers of the MSK cluster (aws kafka get-bootstrap-brokers) and check the VPC DNS settings"
+		}
+		result(StepDNS, CheckFail, err.Error(), hint)
+		return false
+	}
+	result(StepDNS, CheckOK, fmt.Sprintf("%s in %s", strings.Join(addrs, ", "), since(start)), "")
+
+	// TCP
+	start = time.Now()
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		result(StepTCP, CheckFail, err.Error(), c.dialHint(host, port, err))
+		return false
+	}
+	result(StepTCP, CheckOK, fmt.Sprintf("connected to %s in %s", conn.RemoteAddr(), since(start)), "")
+
+	// TLS
+	if c.TLSEnabled && c.tlsConfig != nil {
+		check := c.diagnoseTLS(ctx, conn, host, port, timeout)
+		check.Broker = addr
+		report(check)
+		if check.Status == CheckFail {
+			conn.Close()
+			return false
+		}
+	}
+	conn.Close()
+
+	// SASL and ApiVersions, on a client connected to this broker only
+	return c.diagnoseAPIVersions(ctx, addr, host, port, timeout, result)
+}
+
+// diagnoseTLS completes a TLS handshake on conn and verifies the broker certificate
+func (c *Config) diagnoseTLS(ctx context.Context, conn net.Conn, host, port string, timeout time.Duration) Check {
+	cfg := c.tlsConfig.Clone()
+	serverName := cfg.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+	cfg.ServerName = serverName
+	// Verified below, so that the chain is reported even when it is rejected
+	verify := !cfg.InsecureSkipVerify
+	cfg.InsecureSkipVerify = true
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return Check{Step: StepTLS, Status: CheckFail, Detail: err.Error(), Hint: c.tlsHint(host, port, err)}
+	}
+
+	state := tlsConn.ConnectionState()
+	check := Check{
+		Step:   StepTLS,
+		Status: CheckOK,
+		Detail: fmt.Sprintf("%s, %s", tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite)),
+	}
+	for i, cert := range state.PeerCertificates {
+		check.Details = append(check.Details, fmt.Sprintf("%d: %s", i, describeCertificate(cert)))
+	}
+	if len(state.PeerCertificates) == 0 {
+		check.Status = CheckFail
+		check.Detail += ", no certificate"
+		return check
+	}
+	leaf := state.PeerCertificates[0]
+
+	if !verify {
+		check.Status = CheckWarn
+		check.Detail += ", certificate not verified"
+		check.Hint = "KAFKA_TLS_INSECURE_SKIP_VERIFY is set: set KAFKA_TLS_CA_FILE instead"
+		return check
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         c.tlsConfig.RootCAs,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+	var (
+		hostErr      x509.HostnameError
+		authorityErr x509.UnknownAuthorityError
+		invalidErr   x509.CertificateInvalidError
+	)
+	switch {
+	case err == nil:
+		check.Detail += ", verified for " + serverName
+	case errors.As(err, &hostErr):
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("certificate is not valid for %s, only for %s", serverName, strings.Join(certificateNames(leaf), ", "))
+		check.Hint = "connect with a name of the certificate, or set KAFKA_TLS_SERVER_NAME to one"
+	case errors.As(err, &authorityErr):
+		check.Status = CheckFail
+		check.Detail = "certificate signed by an unknown authority: " + leaf.Issuer.String()
+		check.Hint = "set KAFKA_TLS_CA_FILE to the CA certificate that signed the broker certificates"
+	case errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired:
+		check.Status = CheckFail
+		check.Detail = err.Error()
+		check.Hint = "a certificate of the chain expired or is not valid yet: renew it on the broker, or check the clock of this host"
+	default:
+		check.Status = CheckFail
+		check.Detail = err.Error()
+	}
+	if check.Status == CheckOK && time.Until(leaf.NotAfter) < certExpiryWarning {
+		check.Status = CheckWarn
+		check.Hint = fmt.Sprintf("the broker certificate expires on %s", leaf.NotAfter.Format(time.DateOnly))
+	}
+	return check
+}
+
+// diagnoseAPIVersions authenticates to the broker and fetches its API versions
+func (c *Config) diagnoseAPIVersions(ctx context.Context, addr, host, port string, timeout time.Duration, result func(string, CheckStatus, string, string)) bool {
+	opts := append(c.getBaseOptions(), kgo.SeedBrokers(addr), kgo.DialTimeout(timeout), kgo.RequestRetries(0))
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		result(StepAPIVersions, CheckFail, err.Error(), "")
+		return false
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := kmsg.NewPtrApiVersionsRequest().RequestWith(ctx, client)
+	if err == nil {
+		err = kerr.ErrorForCode(resp.ErrorCode)
+	}
+
+	saslEnabled := c.UseAWSIAM || c.SASLMechanism != ""
+	if err != nil {
+		if saslEnabled && isSASLError(err) {
+			result(StepSASL, CheckFail, err.Error(), c.saslHint(host, port, err))
+			return false
+		}
+		result(StepAPIVersions, CheckFail, err.Error(), c.connectionHint(host, port, err))
+		return false
+	}
+
+	if saslEnabled {
+		result(StepSASL, CheckOK, "authenticated with "+c.authName(), "")
+	}
+	versions := kversion.FromApiVersionsResponse(resp)
+	result(StepAPIVersions, CheckOK, fmt.Sprintf("%d APIs, Kafka %s", len(resp.ApiKeys), versions.VersionGuess()), "")
+	return true
+}
+
+// diagnoseMetadata fetches the cluster metadata and checks that the brokers
+// advertise addresses this host can resolve
+func (c *Config) diagnoseMetadata(ctx context.Context, timeout time.Duration, report func(Check)) {
+	client, err := kgo.NewClient(append(c.getBaseOptions(), kgo.DialTimeout(timeout), kgo.RequestRetries(0))...)
+	if err != nil {
+		report(Check{Step: StepMetadata, Status: CheckFail, Detail: err.Error()})
+		return
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	meta, err := kadm.NewClient(client).BrokerMetadata(ctx)
+	if err != nil {
+		hint := c.connectionHint("", "", err)
+		if errors.Is(err, kerr.ClusterAuthorizationFailed) {
+			hint = "the principal may not describe the cluster: grant kafka-cluster:DescribeCluster (MSK IAM) or a Describe ACL on the cluster"
+		}
+		report(Check{Step: StepMetadata, Status: CheckFail, Detail: err.Error(), Hint: hint})
+		return
+	}
+
+	check := Check{
+		Step:   StepMetadata,
+		Status: CheckOK,
+		Detail: fmt.Sprintf("cluster %s, %d broker(s), controller %d", meta.Cluster, len(meta.Brokers), meta.Controller),
+	}
+	var unresolved []string
+	for _, b := range meta.Brokers {
+		line := fmt.Sprintf("%d: %s", b.NodeID, net.JoinHostPort(b.Host, fmt.Sprint(b.Port)))
+		if b.Rack != nil {
+			line += " rack " + *b.Rack
+		}
+		check.Details = append(check.Details, line)
+
+		lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+		if _, err := net.DefaultResolver.LookupHost(lookupCtx, b.Host); err != nil || isLoopback(b.Host) && !c.seedsAreLoopback() {
+			unresolved = append(unresolved, b.Host)
+		}
+		cancel()
+	}
+	if len(unresolved) > 0 {
+		check.Status = CheckWarn
+		check.Hint = fmt.Sprintf("brokers advertise %s, which this host cannot reach: fix advertised.listeners (KAFKA_ADVERTISED_LISTENERS with Docker)", strings.Join(unresolved, ", "))
+	}
+	report(check)
+}
+
+// dialHint explains a failed TCP connection
+func (c *Config) dialHint(host, port string, err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		hint := fmt.Sprintf("nothing listens on port %s of %s: check the port", port, host)
+		if isMSKBroker(host) {
+			hint += " (MSK: 9092 plaintext, 9094 TLS, 9096 SASL/SCRAM, 9098 IAM)"
+		}
+		return hint
+	case errors.As(err, &netErr) && netErr.Timeout():
+		hint := fmt.Sprintf("the connection timed out: a security group, network ACL or firewall drops traffic to port %s", port)
+		if isMSKBroker(host) {
+			hint += "; allow it in the security group of the MSK cluster, from a network peered with its VPC"
+		}
+		return hint
+	}
+	return ""
+}
+
+// tlsHint explains a failed TLS handshake
+func (c *Config) tlsHint(host, port string, err error) string {
+	var recordErr tls.RecordHeaderError
+	switch {
+	case errors.As(err, &recordErr):
+		return fmt.Sprintf("port %s does not speak TLS: use the TLS listener port, or set KAFKA_TLS_ENABLED=false", port)
+	case errors.Is(err, io.EOF), errors.Is(err, syscall.ECONNRESET):
+		return "the broker closed the connection during the handshake: it may not speak TLS on this port"
+	}
+	return c.connectionHint(host, port, err)
+}
+
+// saslHint explains a rejected authentication
+func (c *Config) saslHint(host, port string, err error) string {
+	if errors.Is(err, kerr.UnsupportedSaslMechanism) || errors.Is(err, kerr.IllegalSaslState) {
+		if hint := c.portHint(host, port); hint != "" {
+			return hint
+		}
+		return fmt.Sprintf("the listener on port %s does not enable %s: check the port and KAFKA_SASL_MECHANISM", port, c.authName())
+	}
+	switch {
+	case c.UseAWSIAM:
+		return "check that the IAM identity is allowed kafka-cluster:Connect on the cluster (see AWS_MSK_CONFIG.md) and that IAM access control is enabled on it"
+	case isSCRAM(c.SASLMechanism):
+		return "check the username and password, and that the user has credentials for " + c.SASLMechanism + " (SCRAM-SHA-256 and SCRAM-SHA-512 are separate)"
+	case c.SASLMechanism == SASLOAuthBearer:
+		return "check that the broker accepts tokens of this identity provider (issuer, audience and scopes)"
+	}
+	return "check the username and password"
+}
+
+// connectionHint explains an error of the Kafka protocol exchange
+func (c *Config) connectionHint(host, port string, err error) string {
+	text := err.Error()
+	switch {
+	case strings.Contains(text, "certificate required"), strings.Contains(text, "bad certificate"), strings.Contains(text, "unknown certificate authority"):
+		return "the broker rejected the client certificate: set KAFKA_TLS_CERT_FILE and KAFKA_TLS_KEY_FILE (or KAFKA_TLS_PKCS12_FILE) to a certificate signed by a CA the broker trusts"
+	case errors.Is(err, io.EOF), errors.Is(err, syscall.ECONNRESET), strings.Contains(text, "connection reset"):
+		if !c.TLSEnabled {
+			return "the broker closed the connection: the listener may require TLS (KAFKA_TLS_ENABLED=true) or SASL authentication"
+		}
+		if !c.UseAWSIAM && c.SASLMechanism == "" {
+			return "the broker closed the connection: the listener may require SASL authentication (KAFKA_SASL_MECHANISM or KAFKA_USE_AWS_IAM)"
+		}
+		return "the broker closed the connection: check that the port matches the TLS and SASL settings"
+	case errors.Is(err, context.DeadlineExceeded):
+		if hint := c.portHint(host, port); hint != "" {
+			return hint
+		}
+		return "the broker did not answer in time: check that the port matches the TLS and SASL settings"
+	}
+	return ""
+}
+
+// authName names the authentication in use
+func (c *Config) authName() string {
+	switch {
+	case c.UseAWSIAM:
+		return "MSK IAM"
+	case c.SASLMechanism == SASLOAuthBearer:
+		return SASLOAuthBearer
+	case c.SASLMechanism != "":
+		return c.SASLMechanism + " as " + c.SASLUsername
+	}
+	return "no authentication"
+}
+
+// seedsAreLoopback reports whether all seed brokers are local
+func (c *Config) seedsAreLoopback() bool {
+	for _, broker := range c.Brokers {
+		if host, _ := splitBroker(broker); !isLoopback(host) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSASLError reports whether err is an authentication failure
+func isSASLError(err error) bool {
+	return errors.Is(err, kerr.SaslAuthenticationFailed) ||
+		errors.Is(err, kerr.UnsupportedSaslMechanism) ||
+		errors.Is(err, kerr.IllegalSaslState)
+}
+
+// describeCertificate summarizes a certificate: subject, issuer, names and expiry
+func describeCertificate(cert *x509.Certificate) string {
+	desc := fmt.Sprintf("%s, issuer %s", cert.Subject, cert.Issuer)
+	if names := cert.DNSNames; len(names) > 0 {
+		desc += ", DNS " + strings.Join(names, " ")
+	}
+	remaining := time.Until(cert.NotAfter)
+	if remaining <= 0 {
+		return desc + fmt.Sprintf(", EXPIRED on %s", cert.NotAfter.Format(time.DateOnly))
+	}
+	return desc + fmt.Sprintf(", expires %s (%d days)", cert.NotAfter.Format(time.DateOnly), int(remaining.Hours()/24))
+}
+
+// certificateNames lists the names a certificate is valid for
+func certificateNames(cert *x509.Certificate) []string {
+	names := slices.Clone(cert.DNSNames)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	if len(names) == 0 && cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName+" (common name only)")
+	}
+	return names
+}
+
+// splitBroker splits a broker address, defaulting to port 9092 like the client
+func splitBroker(broker string) (host, port string) {
+	host, port, err := net.SplitHostPort(broker)
+	if err != nil {
+		return broker, "9092"
+	}
+	return host, port
+}
+
+func isSCRAM(mechanism string) bool {
+	return mechanism == SASLScramSHA256 || mechanism == SASLScramSHA512
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func since(start time.Time) time.Duration {
+	return time.Since(start).Round(time.Millisecond)
+}
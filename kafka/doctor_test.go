@@ -0,0 +1,213 @@
+package kafka
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServerCert returns a self-signed broker certificate for dnsName
+func newTestServerCert(t *testing.T, dnsName string, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: dnsName},
+		DNSNames:              []string{dnsName},
+		NotBefore:             time.Now().Add(-48 * time.Hour),
+		NotAfter:              notAfter,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// newTestListener starts a listener that hands each connection to handle,
+// and returns its address
+func newTestListener(t *testing.T, ln net.Listener, handle func(net.Conn)) string {
+	t.Helper()
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func listenLocal(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ln
+}
+
+// diagnose runs the checks of cfg and returns them by broker step
+func diagnose(cfg *Config) map[string]Check {
+	checks := make(map[string]Check)
+	cfg.Diagnose(context.Background(), 2*time.Second, func(c Check) {
+		checks[c.Step] = c
+	})
+	return checks
+}
+
+func TestDiagnoseConnectionRefused(t *testing.T) {
+	ln := listenLocal(t)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	checks := diagnose(&Config{Brokers: []string{addr}})
+	if checks[StepDNS].Status != CheckOK {
+		t.Fatalf("dns = %+v", checks[StepDNS])
+	}
+	if tcp := checks[StepTCP]; tcp.Status != CheckFail || !strings.Contains(tcp.Hint, "nothing listens") {
+		t.Fatalf("tcp = %+v", tcp)
+	}
+	if checks[StepMetadata].Status != CheckSkip {
+		t.Fatalf("metadata = %+v", checks[StepMetadata])
+	}
+}
+
+func TestTestConnection(t *testing.T) {
+	ln := listenLocal(t)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	err := (&Config{Brokers: []string{addr}}).TestConnection(context.Background())
+	if err == nil || !strings.Contains(err.Error(), addr+" tcp") || !strings.Contains(err.Error(), "nothing listens") {
+		t.Fatalf("expected the tcp failure of %s, got %v", addr, err)
+	}
+}
+
+func TestDiagnoseBrokerClosesConnection(t *testing.T) {
+	addr := newTestListener(t, listenLocal(t), func(net.Conn) {})
+
+	checks := diagnose(&Config{Brokers: []string{addr}})
+	if checks[StepTCP].Status != CheckOK {
+		t.Fatalf("tcp = %+v", checks[StepTCP])
+	}
+	api := checks[StepAPIVersions]
+	if api.Status != CheckFail || !strings.Contains(api.Hint, "KAFKA_TLS_ENABLED=true") {
+		t.Fatalf("api-versions = %+v", api)
+	}
+}
+
+func TestDiagnoseTLSOnPlaintextListener(t *testing.T) {
+	addr := newTestListener(t, listenLocal(t), func(conn net.Conn) {
+		_, _ = conn.Write([]byte("HTTP/1.0 400 Bad Request\r\n\r\n"))
+	})
+
+	checks := diagnose(&Config{Brokers: []string{addr}, TLSEnabled: true, tlsConfig: &tls.Config{}})
+	if tlsCheck := checks[StepTLS]; tlsCheck.Status != CheckFail || !strings.Contains(tlsCheck.Hint, "does not speak TLS") {
+		t.Fatalf("tls = %+v", tlsCheck)
+	}
+}
+
+func TestDiagnoseTLSCertificate(t *testing.T) {
+	cert := newTestServerCert(t, "kafka.internal", time.Now().Add(365*24*time.Hour))
+	ln := tls.NewListener(listenLocal(t), &tls.Config{Certificates: []tls.Certificate{cert}})
+	addr := newTestListener(t, ln, func(conn net.Conn) {
+		_ = conn.(*tls.Conn).Handshake()
+	})
+	roots := x509.NewCertPool()
+	roots.AddCert(cert.Leaf)
+
+	tests := []struct {
+		name      string
+		tlsConfig *tls.Config
+		status    CheckStatus
+		detail    string
+		hint      string
+	}{
+		{"verified", &tls.Config{RootCAs: roots, ServerName: "kafka.internal"}, CheckOK, "verified for kafka.internal", ""},
+		{"host name mismatch", &tls.Config{RootCAs: roots}, CheckFail, "not valid for 127.0.0.1, only for kafka.internal", "KAFKA_TLS_SERVER_NAME"},
+		{"unknown authority", &tls.Config{RootCAs: x509.NewCertPool(), ServerName: "kafka.internal"}, CheckFail, "unknown authority", "KAFKA_TLS_CA_FILE"},
+		{"not verified", &tls.Config{InsecureSkipVerify: true}, CheckWarn, "not verified", "KAFKA_TLS_INSECURE_SKIP_VERIFY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := diagnose(&Config{Brokers: []string{addr}, TLSEnabled: true, tlsConfig: tt.tlsConfig})
+			check := checks[StepTLS]
+			if check.Status != tt.status || !strings.Contains(check.Detail, tt.detail) || !strings.Contains(check.Hint, tt.hint) {
+				t.Fatalf("tls = %+v", check)
+			}
+			if len(check.Details) != 1 || !strings.Contains(check.Details[0], "CN=kafka.internal") {
+				t.Fatalf("unexpected chain %v", check.Details)
+			}
+		})
+	}
+}
+
+func TestDiagnoseExpiredCertificate(t *testing.T) {
+	cert := newTestServerCert(t, "kafka.internal", time.Now().Add(-time.Hour))
+	ln := tls.NewListener(listenLocal(t), &tls.Config{Certificates: []tls.Certificate{cert}})
+	addr := newTestListener(t, ln, func(conn net.Conn) {
+		_ = conn.(*tls.Conn).Handshake()
+	})
+	roots := x509.NewCertPool()
+	roots.AddCert(cert.Leaf)
+
+	checks := diagnose(&Config{Brokers: []string{addr}, TLSEnabled: true, tlsConfig: &tls.Config{RootCAs: roots, ServerName: "kafka.internal"}})
+	check := checks[StepTLS]
+	if check.Status != CheckFail || !strings.Contains(check.Hint, "expired") || !strings.Contains(check.Details[0], "EXPIRED") {
+		t.Fatalf("tls = %+v", check)
+	}
+}
+
+func TestPortHint(t *testing.T) {
+	const msk = "b-1.prod.abc123.c2.kafka.eu-west-1.amazonaws.com"
+	tests := []struct {
+		name string
+		cfg  Config
+		host string
+		port string
+		hint string
+	}{
+		{"IAM on the TLS port", Config{UseAWSIAM: true, TLSEnabled: true}, msk, "9094", "port 9098"},
+		{"IAM on the IAM port", Config{UseAWSIAM: true, TLSEnabled: true}, msk, "9098", ""},
+		{"public IAM port", Config{UseAWSIAM: true, TLSEnabled: true}, msk, "9198", ""},
+		{"SCRAM on the IAM port", Config{SASLMechanism: SASLScramSHA512, TLSEnabled: true}, msk, "9098", "port 9096"},
+		{"plaintext on the TLS port", Config{}, msk, "9094", "KAFKA_TLS_ENABLED=true"},
+		{"TLS on the plaintext port", Config{TLSEnabled: true}, msk, "9092", "plaintext listener"},
+		{"no auth on the IAM port", Config{TLSEnabled: true}, msk, "9098", "requires authentication"},
+		{"TLS on the TLS port", Config{TLSEnabled: true}, msk, "9094", ""},
+		{"not MSK", Config{UseAWSIAM: true}, "kafka.internal", "9092", ""},
+	}
+	for _, tt := range tests {
+		hint := tt.cfg.portHint(tt.host, tt.port)
+		if (tt.hint == "" && hint != "") || !strings.Contains(hint, tt.hint) {
+			t.Errorf("%s: portHint() = %q, want %q", tt.name, hint, tt.hint)
+		}
+	}
+}
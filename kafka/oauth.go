@@ -0,0 +1,223 @@
+package kafka
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenRefreshRatio is the part of a token lifetime after which it is refreshed
+const tokenRefreshRatio = 0.8
+
+// tokenSource provides OAUTHBEARER tokens
+type tokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// staticToken is a token set with KAFKA_SASL_OAUTH_TOKEN
+type staticToken string
+
+func (t staticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// clientCredentials fetches tokens from an OAuth2 token endpoint with the
+// client credentials grant and caches them until shortly before they expire
+type clientCredentials struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+// tokenResponse is the successful response of a token endpoint (RFC 6749 section 5.1)
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// tokenError is the error response of a token endpoint (RFC 6749 section 5.2)
+type tokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// loadOAuthConfig reads the OAUTHBEARER settings into cfg: a static token, or
+// a token endpoint with client credentials
+func loadOAuthConfig(cfg *Config) error {
+	token, err := getSecret("KAFKA_SASL_OAUTH_TOKEN")
+	if err != nil {
+		return err
+	}
+	token = strings.TrimSpace(token)
+	endpoint := strings.TrimSpace(getSetting("KAFKA_SASL_OAUTH_TOKEN_ENDPOINT"))
+
+	extensions, err := parseOAuthExtensions(getSetting("KAFKA_SASL_OAUTH_EXTENSIONS"))
+	if err != nil {
+		return fmt.Errorf("invalid KAFKA_SASL_OAUTH_EXTENSIONS: %w", err)
+	}
+	cfg.oauthExtensions = extensions
+
+	switch {
+	case token != "" && endpoint != "":
+		return fmt.Errorf("set only one of KAFKA_SASL_OAUTH_TOKEN and KAFKA_SASL_OAUTH_TOKEN_ENDPOINT")
+	case token != "":
+		cfg.oauthTokens = staticToken(token)
+		return nil
+	case endpoint == "":
+		return fmt.Errorf("KAFKA_SASL_OAUTH_TOKEN_ENDPOINT or KAFKA_SASL_OAUTH_TOKEN is required with KAFKA_SASL_MECHANISM %s", SASLOAuthBearer)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid KAFKA_SASL_OAUTH_TOKEN_ENDPOINT %q: expected an http(s) URL", endpoint)
+	}
+	source := &clientCredentials{
+		endpoint:   endpoint,
+		scopes:     strings.FieldsFunc(getSetting("KAFKA_SASL_OAUTH_SCOPES"), isScopeSeparator),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	if source.clientID, err = getSecret("KAFKA_SASL_OAUTH_CLIENT_ID"); err != nil {
+		return err
+	}
+	if source.clientSecret, err = getSecret("KAFKA_SASL_OAUTH_CLIENT_SECRET"); err != nil {
+		return err
+	}
+	if source.clientID == "" || source.clientSecret == "" {
+		return fmt.Errorf("KAFKA_SASL_OAUTH_CLIENT_ID and KAFKA_SASL_OAUTH_CLIENT_SECRET are required with KAFKA_SASL_OAUTH_TOKEN_ENDPOINT")
+	}
+	cfg.OAuthTokenEndpoint = endpoint
+	cfg.oauthTokens = source
+	return nil
+}
+
+// Token returns the cached token, fetching a new one when it is about to expire
+func (c *clientCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.refreshAt) {
+		return c.token, nil
+	}
+
+	issued := time.Now()
+	resp, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := time.Duration(resp.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		if exp, ok := jwtExpiry(resp.AccessToken); ok {
+			lifetime = exp.Sub(issued)
+		}
+	}
+	c.token = resp.AccessToken
+	// Without a known lifetime, the token is fetched again for every connection
+	c.refreshAt = issued.Add(time.Duration(float64(lifetime) * tokenRefreshRatio))
+	return c.token, nil
+}
+
+// fetch requests a token with the client credentials grant, authenticating with HTTP basic auth
+func (c *clientCredentials) fetch(ctx context.Context) (*tokenResponse, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.scopes) > 0 {
+		form.Set("scope", strings.Join(c.scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OAuth token from %s: %w", c.endpoint, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OAuth token response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		var tokErr tokenError
+		if json.Unmarshal(body, &tokErr) == nil && tokErr.Error != "" {
+			if tokErr.ErrorDescription != "" {
+				return nil, fmt.Errorf("OAuth token endpoint %s: %s: %s", c.endpoint, tokErr.Error, tokErr.ErrorDescription)
+			}
+			return nil, fmt.Errorf("OAuth token endpoint %s: %s", c.endpoint, tokErr.Error)
+		}
+		return nil, fmt.Errorf("OAuth token endpoint %s returned %s", c.endpoint, res.Status)
+	}
+
+	var tok tokenResponse
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("invalid OAuth token response from %s: %w", c.endpoint, err)
+	}
+	if tok.AccessToken == "" {
+		return nil, fmt.Errorf("OAuth token response from %s has no access_token", c.endpoint)
+	}
+	if tok.TokenType != "" && !strings.EqualFold(tok.TokenType, "bearer") {
+		return nil, fmt.Errorf("OAuth token endpoint %s returned a %s token, expected a bearer token", c.endpoint, tok.TokenType)
+	}
+	return &tok, nil
+}
+
+// jwtExpiry returns the exp claim of a JWT, without verifying it
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// parseOAuthExtensions parses SASL extensions given as key=value pairs
+// separated by commas, e.g. logicalCluster=lkc-123,identityPoolId=pool-4
+func parseOAuthExtensions(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	extensions := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		if key == "auth" {
+			return nil, fmt.Errorf("the auth extension is reserved")
+		}
+		extensions[key] = value
+	}
+	return extensions, nil
+}
+
+func isScopeSeparator(r rune) bool {
+	return r == ',' || r == ' '
+}
@@ -0,0 +1,162 @@
+package kafka
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestTokenEndpoint starts a client credentials token endpoint accepting
+// client app/secret, and returns it with a counter of the tokens it issued
+func newTestTokenEndpoint(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	t.Helper()
+	var issued int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "app" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client","error_description":"bad credentials"}`)
+			return
+		}
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "kafka openid" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_request"}`)
+			return
+		}
+		n := atomic.AddInt32(&issued, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &issued
+}
+
+func setOAuthEnv(t *testing.T, endpoint string) {
+	t.Helper()
+	clearSettingsEnv(t)
+	t.Setenv("KAFKA_TLS_ENABLED", "false")
+	t.Setenv("KAFKA_SASL_MECHANISM", "oauthbearer")
+	t.Setenv("KAFKA_SASL_OAUTH_TOKEN_ENDPOINT", endpoint)
+	t.Setenv("KAFKA_SASL_OAUTH_CLIENT_ID", "app")
+	t.Setenv("KAFKA_SASL_OAUTH_CLIENT_SECRET", "secret")
+	t.Setenv("KAFKA_SASL_OAUTH_SCOPES", "kafka,openid")
+	t.Setenv("KAFKA_SASL_OAUTH_EXTENSIONS", "logicalCluster=lkc-1, identityPoolId=pool-2")
+}
+
+func TestOAuthClientCredentialsCachesToken(t *testing.T) {
+	srv, issued := newTestTokenEndpoint(t, 3600)
+	setOAuthEnv(t, srv.URL)
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SASLMechanism != SASLOAuthBearer || cfg.OAuthTokenEndpoint != srv.URL {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if cfg.oauthExtensions["logicalCluster"] != "lkc-1" || cfg.oauthExtensions["identityPoolId"] != "pool-2" {
+		t.Fatalf("unexpected extensions %v", cfg.oauthExtensions)
+	}
+
+	for i := 0; i < 3; i++ {
+		token, err := cfg.oauthTokens.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "token-1" {
+			t.Fatalf("token = %q, want the cached token-1", token)
+		}
+	}
+	if n := atomic.LoadInt32(issued); n != 1 {
+		t.Fatalf("%d tokens fetched, want 1", n)
+	}
+}
+
+func TestOAuthClientCredentialsRefreshesBeforeExpiry(t *testing.T) {
+	srv, _ := newTestTokenEndpoint(t, 1)
+	source := &clientCredentials{
+		endpoint: srv.URL, clientID: "app", clientSecret: "secret",
+		scopes: []string{"kafka", "openid"}, httpClient: srv.Client(),
+	}
+
+	first, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A 1s token is refreshed after 800ms
+	time.Sleep(900 * time.Millisecond)
+	second, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatalf("token %q was not refreshed", first)
+	}
+}
+
+func TestOAuthClientCredentialsErrors(t *testing.T) {
+	srv, _ := newTestTokenEndpoint(t, 3600)
+	source := &clientCredentials{endpoint: srv.URL, clientID: "app", clientSecret: "wrong", httpClient: srv.Client()}
+	_, err := source.Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid_client: bad credentials") {
+		t.Fatalf("expected the token endpoint error, got %v", err)
+	}
+}
+
+func TestOAuthConfigErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"no token source":    {},
+		"token and endpoint": {"KAFKA_SASL_OAUTH_TOKEN": "t", "KAFKA_SASL_OAUTH_TOKEN_ENDPOINT": "https://idp/token"},
+		"missing secret":     {"KAFKA_SASL_OAUTH_TOKEN_ENDPOINT": "https://idp/token", "KAFKA_SASL_OAUTH_CLIENT_ID": "app"},
+		"invalid endpoint":   {"KAFKA_SASL_OAUTH_TOKEN_ENDPOINT": "idp/token", "KAFKA_SASL_OAUTH_CLIENT_ID": "app", "KAFKA_SASL_OAUTH_CLIENT_SECRET": "s"},
+		"invalid extension":  {"KAFKA_SASL_OAUTH_TOKEN": "t", "KAFKA_SASL_OAUTH_EXTENSIONS": "logicalCluster"},
+	}
+	for name, env := range tests {
+		t.Run(name, func(t *testing.T) {
+			clearSettingsEnv(t)
+			t.Setenv("KAFKA_TLS_ENABLED", "false")
+			t.Setenv("KAFKA_SASL_MECHANISM", SASLOAuthBearer)
+			for k, v := range env {
+				t.Setenv(k, v)
+			}
+			if _, err := NewConfig(); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestOAuthStaticToken(t *testing.T) {
+	clearSettingsEnv(t)
+	t.Setenv("KAFKA_TLS_ENABLED", "false")
+	t.Setenv("KAFKA_SASL_MECHANISM", SASLOAuthBearer)
+	t.Setenv("KAFKA_SASL_OAUTH_TOKEN", "static-token")
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := cfg.oauthTokens.Token(context.Background()); err != nil || token != "static-token" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+	if cfg.saslMechanism().Name() != SASLOAuthBearer {
+		t.Fatalf("unexpected mechanism %s", cfg.saslMechanism().Name())
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"app","exp":1700000000}`))
+	exp, ok := jwtExpiry("eyJhbGciOiJub25lIn0." + payload + ".sig")
+	if !ok || exp.Unix() != 1700000000 {
+		t.Fatalf("jwtExpiry() = %v, %v", exp, ok)
+	}
+	if _, ok := jwtExpiry("opaque-token"); ok {
+		t.Fatalf("expected no expiry for an opaque token")
+	}
+}
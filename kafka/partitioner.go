@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// UnassignedPartition marks a record whose partition should be chosen by the
+// producer partitioner. Records produced with CreateProducer must set their
+// Partition to this value unless they target a specific partition.
+const UnassignedPartition int32 = -1
+
+// Supported partitioner names for KAFKA_PARTITIONER and WithPartitioner
+const (
+	// PartitionerDefault spreads keyless records in 64KiB chunks and hashes keys with murmur2
+	PartitionerDefault = "default"
+	// PartitionerSticky pins records to a partition until a batch is full, ignoring keys
+	PartitionerSticky = "sticky"
+	// PartitionerRoundRobin sends each record to the next partition, ignoring keys
+	PartitionerRoundRobin = "round-robin"
+	// PartitionerMurmur2 hashes keys exactly like the Java client, keyless records are sticky
+	PartitionerMurmur2 = "murmur2"
+)
+
+// Partitioners lists the supported partitioner names
+var Partitioners = []string{PartitionerDefault, PartitionerSticky, PartitionerRoundRobin, PartitionerMurmur2}
+
+// ParsePartitioner returns the partitioner registered under name.
+// An empty name selects the default partitioner.
+func ParsePartitioner(name string) (kgo.Partitioner, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", PartitionerDefault:
+		return defaultPartitioner(), nil
+	case PartitionerSticky:
+		return kgo.StickyPartitioner(), nil
+	case PartitionerRoundRobin, "roundrobin":
+		return kgo.RoundRobinPartitioner(), nil
+	case PartitionerMurmur2, "hash":
+		return kgo.StickyKeyPartitioner(nil), nil
+	default:
+		return nil, fmt.Errorf("unknown partitioner %q (expected one of %s)", name, strings.Join(Partitioners, ", "))
+	}
+}
+
+// defaultPartitioner mirrors the franz-go default partitioner
+func defaultPartitioner() kgo.Partitioner {
+	return kgo.UniformBytesPartitioner(64<<10, true, true, nil)
+}
+
+// explicitPartitioner sends records with an explicit Partition to that partition
+// and delegates every other record to the fallback partitioner.
+type explicitPartitioner struct {
+	fallback kgo.Partitioner
+}
+
+func newExplicitPartitioner(fallback kgo.Partitioner) kgo.Partitioner {
+	return &explicitPartitioner{fallback: fallback}
+}
+
+// ForTopic returns a topic partitioner that keeps the fallback's batching behavior
+func (p *explicitPartitioner) ForTopic(topic string) kgo.TopicPartitioner {
+	base := &explicitTopicPartitioner{fallback: p.fallback.ForTopic(topic)}
+	if onNewBatch, ok := base.fallback.(kgo.TopicPartitionerOnNewBatch); ok {
+		return &explicitBatchTopicPartitioner{explicitTopicPartitioner: base, onNewBatch: onNewBatch}
+	}
+	return base
+}
+
+type explicitTopicPartitioner struct {
+	fallback kgo.TopicPartitioner
+}
+
+// RequiresConsistency pins explicit records so partition indices map to partition numbers
+func (p *explicitTopicPartitioner) RequiresConsistency(r *kgo.Record) bool {
+	return r.Partition != UnassignedPartition || p.fallback.RequiresConsistency(r)
+}
+
+// Partition returns the explicit partition or asks the fallback.
+// Out of range partitions are rejected by the client with an error on the record.
+func (p *explicitTopicPartitioner) Partition(r *kgo.Record, n int) int {
+	if r.Partition != UnassignedPartition {
+		return int(r.Partition)
+	}
+	return p.fallback.Partition(r, n)
+}
+
+// PartitionByBackup returns the explicit partition or asks the fallback
+func (p *explicitTopicPartitioner) PartitionByBackup(r *kgo.Record, n int, backup kgo.TopicBackupIter) int {
+	if r.Partition != UnassignedPartition {
+		return int(r.Partition)
+	}
+	if byBackup, ok := p.fallback.(kgo.TopicBackupPartitioner); ok {
+		return byBackup.PartitionByBackup(r, n, backup)
+	}
+	return p.fallback.Partition(r, n)
+}
+
+type explicitBatchTopicPartitioner struct {
+	*explicitTopicPartitioner
+	onNewBatch kgo.TopicPartitionerOnNewBatch
+}
+
+// OnNewBatch forwards new batch notifications to the fallback
+func (p *explicitBatchTopicPartitioner) OnNewBatch() {
+	p.onNewBatch.OnNewBatch()
+}
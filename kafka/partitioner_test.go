@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestExplicitPartitioner(t *testing.T) {
+	fallback := kgo.BasicConsistentPartitioner(func(string) func(*kgo.Record, int) int {
+		return func(*kgo.Record, int) int { return 1 }
+	})
+	p := newExplicitPartitioner(fallback).ForTopic("events")
+
+	explicit := &kgo.Record{Partition: 4}
+	if got := p.Partition(explicit, 6); got != 4 {
+		t.Fatalf("expected explicit partition 4, got %d", got)
+	}
+	if !p.RequiresConsistency(explicit) {
+		t.Fatalf("expected explicit records to require consistency")
+	}
+
+	unassigned := &kgo.Record{Partition: UnassignedPartition}
+	if got := p.Partition(unassigned, 6); got != 1 {
+		t.Fatalf("expected fallback partition 1, got %d", got)
+	}
+}
+
+func TestExplicitPartitionerKeepsBatchNotifications(t *testing.T) {
+	p := newExplicitPartitioner(kgo.StickyPartitioner()).ForTopic("events")
+	if _, ok := p.(kgo.TopicPartitionerOnNewBatch); !ok {
+		t.Fatalf("expected sticky fallback to receive new batch notifications")
+	}
+
+	p = newExplicitPartitioner(kgo.RoundRobinPartitioner()).ForTopic("events")
+	if _, ok := p.(kgo.TopicPartitionerOnNewBatch); ok {
+		t.Fatalf("expected round robin fallback not to receive new batch notifications")
+	}
+}
+
+func TestParsePartitioner(t *testing.T) {
+	for _, name := range append([]string{""}, Partitioners...) {
+		if _, err := ParsePartitioner(name); err != nil {
+			t.Fatalf("expected %q to be supported, got %v", name, err)
+		}
+	}
+	if _, err := ParsePartitioner("random"); err == nil {
+		t.Fatalf("expected unknown partitioner to be rejected")
+	}
+}
+
+func TestNewConfigRejectsUnknownPartitioner(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "localhost:9092")
+	t.Setenv("KAFKA_PARTITIONER", "random")
+
+	if _, err := NewConfig(); err == nil {
+		t.Fatalf("expected invalid KAFKA_PARTITIONER to fail")
+	}
+}
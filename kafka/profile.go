@@ -0,0 +1,426 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/viper"
+)
+
+// DefaultProfilesFile is the name of the profiles file in the home directory
+const DefaultProfilesFile = ".kafka-cli.yaml"
+
+// ErrProfilesFileNotFound is returned when the profiles file does not exist
+var ErrProfilesFileNotFound = errors.New("profiles file not found")
+
+// ProfilesFile is the content of ~/.kafka-cli.yaml: named connection profiles
+// ("contexts"), the one used by default and settings shared by every context.
+//
+//	current-context: local
+//	defaults:
+//	  schema-registry: {url: http://localhost:8081}
+//	contexts:
+//	  local:
+//	    brokers: [localhost:9092]
+//	  prod:
+//	    brokers: [b-1.prod.kafka.eu-west-1.amazonaws.com:9098]
+//	    aws: {iam: true, region: eu-west-1}
+type ProfilesFile struct {
+	Path           string             `mapstructure:"-" json:"-" yaml:"-"`
+	CurrentContext string             `mapstructure:"current-context" json:"current-context,omitempty" yaml:"current-context,omitempty"`
+	Defaults       Profile            `mapstructure:"defaults" json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Contexts       map[string]Profile `mapstructure:"contexts" json:"contexts,omitempty" yaml:"contexts"`
+}
+
+// Profile holds the connection settings of a cluster. Every field maps to the
+// environment variable of the same setting, and environment variables win.
+type Profile struct {
+	Name           string                 `mapstructure:"-" json:"name" yaml:"-"`
+	Brokers        []string               `mapstructure:"brokers" json:"brokers,omitempty" yaml:"brokers,omitempty"`
+	TLS            *ProfileTLS            `mapstructure:"tls" json:"tls,omitempty" yaml:"tls,omitempty"`
+	SASL           *ProfileSASL           `mapstructure:"sasl" json:"sasl,omitempty" yaml:"sasl,omitempty"`
+	AWS            *ProfileAWS            `mapstructure:"aws" json:"aws,omitempty" yaml:"aws,omitempty"`
+	SchemaRegistry *ProfileSchemaRegistry `mapstructure:"schema-registry" json:"schema-registry,omitempty" yaml:"schema-registry,omitempty"`
+	Partitioner    string                 `mapstructure:"partitioner" json:"partitioner,omitempty" yaml:"partitioner,omitempty"`
+}
+
+// ProfileTLS holds the TLS settings of a profile
+type ProfileTLS struct {
+	Enabled            *bool  `mapstructure:"enabled" json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	CAFile             string `mapstructure:"ca-file" json:"ca-file,omitempty" yaml:"ca-file,omitempty"`
+	InsecureSkipVerify *bool  `mapstructure:"insecure-skip-verify" json:"insecure-skip-verify,omitempty" yaml:"insecure-skip-verify,omitempty"`
+	ServerName         string `mapstructure:"server-name" json:"server-name,omitempty" yaml:"server-name,omitempty"`
+	CertFile           string `mapstructure:"cert-file" json:"cert-file,omitempty" yaml:"cert-file,omitempty"`
+	KeyFile            string `mapstructure:"key-file" json:"key-file,omitempty" yaml:"key-file,omitempty"`
+	KeyPassword        string `mapstructure:"key-password" json:"key-password,omitempty" yaml:"key-password,omitempty"`
+	PKCS12File         string `mapstructure:"pkcs12-file" json:"pkcs12-file,omitempty" yaml:"pkcs12-file,omitempty"`
+	Reload             *bool  `mapstructure:"reload" json:"reload,omitempty" yaml:"reload,omitempty"`
+}
+
+// ProfileSASL holds the SASL settings of a profile
+type ProfileSASL struct {
+	Mechanism    string `mapstructure:"mechanism" json:"mechanism,omitempty" yaml:"mechanism,omitempty"`
+	Username     string `mapstructure:"username" json:"username,omitempty" yaml:"username,omitempty"`
+	Password     string `mapstructure:"password" json:"password,omitempty" yaml:"password,omitempty"`
+	PasswordFile string `mapstructure:"password-file" json:"password-file,omitempty" yaml:"password-file,omitempty"`
+
+	// OAUTHBEARER
+	Token         string   `mapstructure:"token" json:"token,omitempty" yaml:"token,omitempty"`
+	TokenEndpoint string   `mapstructure:"token-endpoint" json:"token-endpoint,omitempty" yaml:"token-endpoint,omitempty"`
+	ClientID      string   `mapstructure:"client-id" json:"client-id,omitempty" yaml:"client-id,omitempty"`
+	ClientSecret  string   `mapstructure:"client-secret" json:"client-secret,omitempty" yaml:"client-secret,omitempty"`
+	Scopes        []string `mapstructure:"scopes" json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	Extensions    []string `mapstructure:"extensions" json:"extensions,omitempty" yaml:"extensions,omitempty"`
+}
+
+// ProfileAWS holds the AWS MSK IAM settings of a profile
+type ProfileAWS struct {
+	IAM                  *bool  `mapstructure:"iam" json:"iam,omitempty" yaml:"iam,omitempty"`
+	Region               string `mapstructure:"region" json:"region,omitempty" yaml:"region,omitempty"`
+	Profile              string `mapstructure:"profile" json:"profile,omitempty" yaml:"profile,omitempty"`
+	RoleARN              string `mapstructure:"role-arn" json:"role-arn,omitempty" yaml:"role-arn,omitempty"`
+	ExternalID           string `mapstructure:"external-id" json:"external-id,omitempty" yaml:"external-id,omitempty"`
+	SessionName          string `mapstructure:"session-name" json:"session-name,omitempty" yaml:"session-name,omitempty"`
+	Duration             string `mapstructure:"duration" json:"duration,omitempty" yaml:"duration,omitempty"`
+	WebIdentityTokenFile string `mapstructure:"web-identity-token-file" json:"web-identity-token-file,omitempty" yaml:"web-identity-token-file,omitempty"`
+	WebIdentityRoleARN   string `mapstructure:"web-identity-role-arn" json:"web-identity-role-arn,omitempty" yaml:"web-identity-role-arn,omitempty"`
+}
+
+// ProfileSchemaRegistry holds the Schema Registry settings of a profile
+type ProfileSchemaRegistry struct {
+	URL         string `mapstructure:"url" json:"url,omitempty" yaml:"url,omitempty"`
+	Username    string `mapstructure:"username" json:"username,omitempty" yaml:"username,omitempty"`
+	Password    string `mapstructure:"password" json:"password,omitempty" yaml:"password,omitempty"`
+	BearerToken string `mapstructure:"bearer-token" json:"bearer-token,omitempty" yaml:"bearer-token,omitempty"`
+}
+
+// DefaultProfilesPath returns ~/.kafka-cli.yaml
+func DefaultProfilesPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory: %w", err)
+	}
+	return filepath.Join(home, DefaultProfilesFile), nil
+}
+
+// LoadProfiles reads a profiles file. It returns ErrProfilesFileNotFound when
+// path does not exist. Context names are case insensitive.
+func LoadProfiles(path string) (*ProfilesFile, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrProfilesFileNotFound, path)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if filepath.Ext(path) == "" {
+		v.SetConfigType("yaml")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	file := &ProfilesFile{Path: path}
+	if err := v.Unmarshal(file); err != nil {
+		return nil, fmt.Errorf("invalid profiles file %s: %w", path, err)
+	}
+	for name, profile := range file.Contexts {
+		profile.Name = name
+		file.Contexts[name] = profile
+	}
+	return file, nil
+}
+
+// Names returns the context names in alphabetical order
+func (f *ProfilesFile) Names() []string {
+	names := make([]string, 0, len(f.Contexts))
+	for name := range f.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the named profile merged over the defaults, or the current
+// context when name is empty. It returns nil without error when name is empty
+// and there is no current context.
+func (f *ProfilesFile) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = f.CurrentContext
+		if name == "" {
+			return nil, nil
+		}
+	}
+	profile, ok := f.Contexts[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("context %q not found in %s (available: %s)", name, f.Path, strings.Join(f.Names(), ", "))
+	}
+	merged := profile.withDefaults(f.Defaults)
+	return &merged, nil
+}
+
+// withDefaults returns p with its unset settings taken from defaults
+func (p Profile) withDefaults(defaults Profile) Profile {
+	if len(p.Brokers) == 0 {
+		p.Brokers = defaults.Brokers
+	}
+	if p.Partitioner == "" {
+		p.Partitioner = defaults.Partitioner
+	}
+	if defaults.TLS != nil {
+		tls := *defaults.TLS
+		if p.TLS != nil {
+			tls = mergeStruct(tls, *p.TLS)
+		}
+		p.TLS = &tls
+	}
+	if defaults.SASL != nil {
+		sasl := *defaults.SASL
+		if p.SASL != nil {
+			sasl = mergeStruct(sasl, *p.SASL)
+		}
+		p.SASL = &sasl
+	}
+	if defaults.AWS != nil {
+		aws := *defaults.AWS
+		if p.AWS != nil {
+			aws = mergeStruct(aws, *p.AWS)
+		}
+		p.AWS = &aws
+	}
+	if defaults.SchemaRegistry != nil {
+		registry := *defaults.SchemaRegistry
+		if p.SchemaRegistry != nil {
+			registry = mergeStruct(registry, *p.SchemaRegistry)
+		}
+		p.SchemaRegistry = &registry
+	}
+	return p
+}
+
+// mergeStruct returns base with every non-zero field of override copied over
+func mergeStruct[T any](base, override T) T {
+	b := reflect.ValueOf(&base).Elem()
+	o := reflect.ValueOf(override)
+	for i := 0; i < o.NumField(); i++ {
+		if !o.Field(i).IsZero() {
+			b.Field(i).Set(o.Field(i))
+		}
+	}
+	return base
+}
+
+// currentContextLine matches the top level current-context line of a profiles file
+var currentContextLine = regexp.MustCompile(`(?m)^current-context:.*$`)
+
+// SetCurrentContext makes name the current context of the profiles file at path.
+// Only the current-context line is rewritten so comments and formatting are kept.
+func SetCurrentContext(path, name string) error {
+	file, err := LoadProfiles(path)
+	if err != nil {
+		return err
+	}
+	profile, err := file.Profile(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	line := []byte("current-context: " + profile.Name)
+	if currentContextLine.Match(data) {
+		data = currentContextLine.ReplaceAllLiteral(data, line)
+	} else {
+		data = append(append(line, '\n'), data...)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, info.Mode().Perm())
+}
+
+// settings returns the profile as the environment variables it stands for
+func (p *Profile) settings() map[string]string {
+	s := make(map[string]string)
+	set := func(key, value string) {
+		if value != "" {
+			s[key] = value
+		}
+	}
+	setBool := func(key string, value *bool) {
+		if value != nil {
+			s[key] = strconv.FormatBool(*value)
+		}
+	}
+
+	set("KAFKA_BROKERS", strings.Join(p.Brokers, ","))
+	set("KAFKA_PARTITIONER", p.Partitioner)
+	if p.TLS != nil {
+		setBool("KAFKA_TLS_ENABLED", p.TLS.Enabled)
+		set("KAFKA_TLS_CA_FILE", p.TLS.CAFile)
+		setBool("KAFKA_TLS_INSECURE_SKIP_VERIFY", p.TLS.InsecureSkipVerify)
+		set("KAFKA_TLS_SERVER_NAME", p.TLS.ServerName)
+		set("KAFKA_TLS_CERT_FILE", p.TLS.CertFile)
+		set("KAFKA_TLS_KEY_FILE", p.TLS.KeyFile)
+		set("KAFKA_TLS_KEY_PASSWORD", p.TLS.KeyPassword)
+		set("KAFKA_TLS_PKCS12_FILE", p.TLS.PKCS12File)
+		setBool("KAFKA_TLS_RELOAD", p.TLS.Reload)
+	}
+	if p.SASL != nil {
+		set("KAFKA_SASL_MECHANISM", p.SASL.Mechanism)
+		set("KAFKA_SASL_USERNAME", p.SASL.Username)
+		set("KAFKA_SASL_PASSWORD", p.SASL.Password)
+		set("KAFKA_SASL_PASSWORD_FILE", p.SASL.PasswordFile)
+		set("KAFKA_SASL_OAUTH_TOKEN", p.SASL.Token)
+		set("KAFKA_SASL_OAUTH_TOKEN_ENDPOINT", p.SASL.TokenEndpoint)
+		set("KAFKA_SASL_OAUTH_CLIENT_ID", p.SASL.ClientID)
+		set("KAFKA_SASL_OAUTH_CLIENT_SECRET", p.SASL.ClientSecret)
+		set("KAFKA_SASL_OAUTH_SCOPES", strings.Join(p.SASL.Scopes, ","))
+		set("KAFKA_SASL_OAUTH_EXTENSIONS", strings.Join(p.SASL.Extensions, ","))
+	}
+	if p.AWS != nil {
+		setBool("KAFKA_USE_AWS_IAM", p.AWS.IAM)
+		set("AWS_REGION", p.AWS.Region)
+		set("AWS_PROFILE", p.AWS.Profile)
+		set("KAFKA_AWS_ROLE_ARN", p.AWS.RoleARN)
+		set("KAFKA_AWS_EXTERNAL_ID", p.AWS.ExternalID)
+		set("KAFKA_AWS_ROLE_SESSION_NAME", p.AWS.SessionName)
+		set("KAFKA_AWS_ROLE_DURATION", p.AWS.Duration)
+		set("AWS_WEB_IDENTITY_TOKEN_FILE", p.AWS.WebIdentityTokenFile)
+		set("AWS_ROLE_ARN", p.AWS.WebIdentityRoleARN)
+	}
+	if p.SchemaRegistry != nil {
+		set("SCHEMA_REGISTRY_URL", p.SchemaRegistry.URL)
+		set("SCHEMA_REGISTRY_USERNAME", p.SchemaRegistry.Username)
+		set("SCHEMA_REGISTRY_PASSWORD", p.SchemaRegistry.Password)
+		set("SCHEMA_REGISTRY_BEARER_TOKEN", p.SchemaRegistry.BearerToken)
+	}
+	return s
+}
+
+var (
+	activeProfileMu sync.RWMutex
+	activeProfile   *Profile
+	activeSettings  map[string]string
+	profileSelected bool // the profile wins over dotEnvSettings
+	settingOverride = make(map[string]string)
+	dotEnvSettings  = make(map[string]string)
+)
+
+// LoadDotEnv loads the variables of a .env file into the environment, without
+// overriding exported variables, like godotenv.Load. Unlike exported variables,
+// they don't override a profile selected with SelectProfile, and secret
+// references are not resolved from them. A missing file is not an error.
+func LoadDotEnv(path string) error {
+	values, err := godotenv.Read(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	activeProfileMu.Lock()
+	defer activeProfileMu.Unlock()
+	for key, val := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, val); err != nil {
+			return err
+		}
+		dotEnvSettings[key] = val
+	}
+	return nil
+}
+
+// OverrideSetting sets a setting that wins over the environment and the
+// profile, e.g. from a command line flag. An empty value removes the override.
+func OverrideSetting(key, value string) {
+	activeProfileMu.Lock()
+	defer activeProfileMu.Unlock()
+	if value == "" {
+		delete(settingOverride, key)
+		return
+	}
+	settingOverride[key] = value
+}
+
+// UseProfile makes NewConfig read the settings of profile that are not set in
+// the environment. A nil profile only uses the environment.
+func UseProfile(profile *Profile) {
+	useProfile(profile, false)
+}
+
+// SelectProfile is UseProfile for a profile selected explicitly, e.g. with
+// --profile: its settings also win over the variables loaded by LoadDotEnv,
+// so that a .env file of the working directory doesn't redirect it.
+func SelectProfile(profile *Profile) {
+	useProfile(profile, true)
+}
+
+func useProfile(profile *Profile, selected bool) {
+	activeProfileMu.Lock()
+	defer activeProfileMu.Unlock()
+	activeProfile = profile
+	activeSettings = nil
+	profileSelected = selected && profile != nil
+	if profile != nil {
+		activeSettings = profile.settings()
+	}
+}
+
+// ActiveProfile returns the profile set with UseProfile, or nil
+func ActiveProfile() *Profile {
+	activeProfileMu.RLock()
+	defer activeProfileMu.RUnlock()
+	return activeProfile
+}
+
+// lookupSetting returns an overridden setting, or a setting from the
+// environment, or from the active profile when the environment variable is
+// unset or empty. A selected profile also wins over the variables of .env.
+func lookupSetting(key string) (string, bool) {
+	activeProfileMu.RLock()
+	defer activeProfileMu.RUnlock()
+	if val, ok := settingOverride[key]; ok {
+		return val, true
+	}
+	profileVal, inProfile := activeSettings[key]
+	if val, ok := os.LookupEnv(key); ok && val != "" {
+		fromDotEnv := dotEnvSettings[key] == val
+		if !(inProfile && profileSelected && fromDotEnv) {
+			return val, true
+		}
+	}
+	return profileVal, inProfile
+}
+
+// isDotEnvSetting reports whether value, read from the setting key, comes from
+// the variables loaded by LoadDotEnv rather than from the selected profile
+func isDotEnvSetting(key, value string) bool {
+	activeProfileMu.RLock()
+	defer activeProfileMu.RUnlock()
+	if val, ok := dotEnvSettings[key]; !ok || val != value || os.Getenv(key) != value {
+		return false
+	}
+	return !(profileSelected && activeSettings[key] == value)
+}
+
+// getSetting is lookupSetting without the presence flag
+func getSetting(key string) string {
+	val, _ := lookupSetting(key)
+	return val
+}
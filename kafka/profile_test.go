@@ -0,0 +1,233 @@
+package kafka
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testProfiles = `# clusters
+current-context: local
+defaults:
+  partitioner: sticky
+  schema-registry:
+    url: http://registry:8081
+contexts:
+  local:
+    brokers: [localhost:9092]
+    tls: {enabled: false}
+  Staging:
+    brokers:
+      - kafka-1.staging:9093
+      - kafka-2.staging:9093
+    tls: {enabled: false}
+    sasl: {mechanism: SCRAM-SHA-512, username: app, password: secret}
+    schema-registry:
+      username: registry-user
+`
+
+func writeProfiles(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kafka-cli.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clearSettingsEnv empties the environment variables a profile stands for
+func clearSettingsEnv(t *testing.T) {
+	t.Helper()
+	for key := range (&Profile{
+		Brokers:     []string{"x"},
+		Partitioner: "x",
+		TLS: &ProfileTLS{
+			Enabled: new(bool), CAFile: "x", InsecureSkipVerify: new(bool), ServerName: "x",
+			CertFile: "x", KeyFile: "x", KeyPassword: "x", PKCS12File: "x", Reload: new(bool),
+		},
+		SASL: &ProfileSASL{
+			Mechanism: "x", Username: "x", Password: "x", PasswordFile: "x", Token: "x",
+			TokenEndpoint: "x", ClientID: "x", ClientSecret: "x", Scopes: []string{"x"}, Extensions: []string{"x"},
+		},
+		AWS: &ProfileAWS{
+			IAM: new(bool), Region: "x", Profile: "x", RoleARN: "x", ExternalID: "x",
+			SessionName: "x", Duration: "x", WebIdentityTokenFile: "x", WebIdentityRoleARN: "x",
+		},
+		SchemaRegistry: &ProfileSchemaRegistry{URL: "x", Username: "x", Password: "x", BearerToken: "x"},
+	}).settings() {
+		t.Setenv(key, "")
+	}
+}
+
+func TestLoadProfilesMergesDefaults(t *testing.T) {
+	file, err := LoadProfiles(writeProfiles(t, testProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(file.Names(), []string{"local", "staging"}) {
+		t.Fatalf("Names() = %v", file.Names())
+	}
+
+	current, err := file.Profile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Name != "local" || current.Partitioner != PartitionerSticky || current.SchemaRegistry.URL != "http://registry:8081" {
+		t.Fatalf("unexpected current profile %+v", current)
+	}
+
+	staging, err := file.Profile("STAGING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(staging.Brokers) != 2 || staging.SASL.Mechanism != "SCRAM-SHA-512" {
+		t.Fatalf("unexpected staging profile %+v", staging)
+	}
+	if staging.SchemaRegistry.URL != "http://registry:8081" || staging.SchemaRegistry.Username != "registry-user" {
+		t.Fatalf("schema registry not merged with defaults: %+v", staging.SchemaRegistry)
+	}
+	if file.Defaults.SchemaRegistry.Username != "" {
+		t.Fatalf("merging modified the defaults")
+	}
+
+	if _, err := file.Profile("prod"); err == nil || !strings.Contains(err.Error(), "local, staging") {
+		t.Fatalf("expected an unknown context error listing the contexts, got %v", err)
+	}
+}
+
+func TestLoadProfilesMissingFile(t *testing.T) {
+	_, err := LoadProfiles(filepath.Join(t.TempDir(), "missing.yaml"))
+	if !errors.Is(err, ErrProfilesFileNotFound) {
+		t.Fatalf("expected ErrProfilesFileNotFound, got %v", err)
+	}
+}
+
+func TestNewConfigFromProfile(t *testing.T) {
+	clearSettingsEnv(t)
+	file, err := LoadProfiles(writeProfiles(t, testProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	staging, err := file.Profile("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	UseProfile(staging)
+	t.Cleanup(func() { UseProfile(nil) })
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "staging" || cfg.TLSEnabled || len(cfg.Brokers) != 2 || cfg.SchemaRegistryURL != "http://registry:8081" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	// Environment variables override the profile
+	t.Setenv("KAFKA_BROKERS", "override:9092")
+	t.Setenv("KAFKA_PARTITIONER", PartitionerRoundRobin)
+	cfg, err = NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Brokers, []string{"override:9092"}) || cfg.Partitioner != PartitionerRoundRobin {
+		t.Fatalf("environment did not override the profile: %+v", cfg)
+	}
+}
+
+func TestSetCurrentContextKeepsComments(t *testing.T) {
+	path := writeProfiles(t, testProfiles)
+	if err := SetCurrentContext(path, "Staging"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# clusters") || !strings.Contains(string(data), "current-context: staging") {
+		t.Fatalf("unexpected file after use-context:\n%s", data)
+	}
+
+	if err := SetCurrentContext(path, "prod"); err == nil {
+		t.Fatalf("expected an unknown context error")
+	}
+
+	path = writeProfiles(t, "contexts:\n  dev:\n    brokers: [dev:9092]\n")
+	if err := SetCurrentContext(path, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	file, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.CurrentContext != "dev" {
+		t.Fatalf("current-context = %q, want dev", file.CurrentContext)
+	}
+}
+
+// loadTestDotEnv loads a .env file with content, the variables it sets unset
+// first unless the test exported them
+func loadTestDotEnv(t *testing.T, content string) {
+	t.Helper()
+	dotEnv := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(dotEnv, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		key, _, _ := strings.Cut(line, "=")
+		if os.Getenv(key) == "" {
+			t.Setenv(key, "")
+			os.Unsetenv(key)
+		}
+	}
+	if err := LoadDotEnv(dotEnv); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for key := range dotEnvSettings {
+			delete(dotEnvSettings, key)
+		}
+	})
+}
+
+func TestSelectedProfileWinsOverDotEnv(t *testing.T) {
+	clearSettingsEnv(t)
+	t.Setenv("KAFKA_SASL_USERNAME", "exported")
+	loadTestDotEnv(t, "KAFKA_BROKERS=localhost:9092\nKAFKA_TLS_SERVER_NAME=kafka.local\nKAFKA_SASL_USERNAME=dotenv\n")
+	t.Cleanup(func() { UseProfile(nil) })
+
+	file, err := LoadProfiles(writeProfiles(t, testProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	staging, err := file.Profile("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// .env overrides the current context
+	UseProfile(staging)
+	if got := getSetting("KAFKA_BROKERS"); got != "localhost:9092" {
+		t.Fatalf("current context: KAFKA_BROKERS = %q", got)
+	}
+
+	// but not a profile selected explicitly, only exported variables do
+	SelectProfile(staging)
+	tests := map[string]string{
+		"KAFKA_BROKERS":         "kafka-1.staging:9093,kafka-2.staging:9093",
+		"KAFKA_TLS_SERVER_NAME": "kafka.local",
+		"KAFKA_SASL_USERNAME":   "exported",
+	}
+	for key, want := range tests {
+		if got := getSetting(key); got != want {
+			t.Errorf("selected profile: %s = %q, want %q", key, got, want)
+		}
+	}
+	t.Setenv("KAFKA_BROKERS", "exported:9092")
+	if got := getSetting("KAFKA_BROKERS"); got != "exported:9092" {
+		t.Fatalf("exported KAFKA_BROKERS = %q", got)
+	}
+}
@@ -0,0 +1,277 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema types as reported by the Schema Registry. Avro schemas are usually
+// returned without a schemaType.
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+	SchemaTypeJSON     = "JSON"
+)
+
+// LatestVersion selects the latest version of a subject
+const LatestVersion = "latest"
+
+// ErrSchemaRegistryNotConfigured is returned when no Schema Registry URL is set
+var ErrSchemaRegistryNotConfigured = errors.New("no schema registry configured (set SCHEMA_REGISTRY_URL)")
+
+// Schema is a schema stored in the Schema Registry
+type Schema struct {
+	ID         int               `json:"id,omitempty"`
+	Subject    string            `json:"subject,omitempty"`
+	Version    int               `json:"version,omitempty"`
+	SchemaType string            `json:"schemaType,omitempty"`
+	Schema     string            `json:"schema"`
+	References []SchemaReference `json:"references,omitempty"`
+}
+
+// Type returns the schema type, defaulting to Avro
+func (s *Schema) Type() string {
+	if s.SchemaType == "" {
+		return SchemaTypeAvro
+	}
+	return strings.ToUpper(s.SchemaType)
+}
+
+// SchemaReference points to another subject version used by a schema.
+// Name is the Avro type name, the Protobuf import path or the JSON Schema $ref.
+type SchemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// RegistryError is an error response of the Schema Registry
+type RegistryError struct {
+	StatusCode int
+	Code       int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *RegistryError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("schema registry returned HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("schema registry: %s (error %d)", e.Message, e.Code)
+}
+
+// IsNotFound reports whether err is a Schema Registry "not found" error
+func IsNotFound(err error) bool {
+	var regErr *RegistryError
+	return errors.As(err, &regErr) && regErr.StatusCode == http.StatusNotFound
+}
+
+// SchemaRegistry is a Schema Registry client. Schemas and their codecs are
+// cached for the lifetime of the client, so it is meant to be shared by every
+// record of a command. It is safe for concurrent use.
+type SchemaRegistry struct {
+	baseURL    string
+	username   string
+	password   string
+	token      string
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	byID     map[int]*Schema
+	subjects map[string]*Schema
+	codecs   map[int]schemaCodec
+}
+
+// RegistryOption configures a SchemaRegistry
+type RegistryOption func(*SchemaRegistry)
+
+// WithRegistryBasicAuth authenticates with a username and password (or API key and secret)
+func WithRegistryBasicAuth(username, password string) RegistryOption {
+	return func(r *SchemaRegistry) {
+		r.username, r.password = username, password
+	}
+}
+
+// WithRegistryBearerToken authenticates with a bearer token
+func WithRegistryBearerToken(token string) RegistryOption {
+	return func(r *SchemaRegistry) {
+		r.token = token
+	}
+}
+
+// WithRegistryHTTPClient replaces the HTTP client used to reach the registry
+func WithRegistryHTTPClient(client *http.Client) RegistryOption {
+	return func(r *SchemaRegistry) {
+		r.httpClient = client
+	}
+}
+
+// NewSchemaRegistry creates a client for the registry at baseURL
+func NewSchemaRegistry(baseURL string, opts ...RegistryOption) (*SchemaRegistry, error) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+	if u, err := url.Parse(baseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid schema registry URL %q", baseURL)
+	}
+
+	r := &SchemaRegistry{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		byID:       make(map[int]*Schema),
+		subjects:   make(map[string]*Schema),
+		codecs:     make(map[int]schemaCodec),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// URL returns the registry base URL
+func (r *SchemaRegistry) URL() string {
+	return r.baseURL
+}
+
+// SchemaByID returns the schema registered with id
+func (r *SchemaRegistry) SchemaByID(ctx context.Context, id int) (*Schema, error) {
+	r.mu.Lock()
+	schema, ok := r.byID[id]
+	r.mu.Unlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema = &Schema{}
+	if err := r.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, schema); err != nil {
+		return nil, fmt.Errorf("failed to get schema %d: %w", id, err)
+	}
+	schema.ID = id
+
+	r.mu.Lock()
+	r.byID[id] = schema
+	r.mu.Unlock()
+	return schema, nil
+}
+
+// SubjectSchema returns a version of subject, or its latest version when
+// version is empty or LatestVersion
+func (r *SchemaRegistry) SubjectSchema(ctx context.Context, subject, version string) (*Schema, error) {
+	if version == "" {
+		version = LatestVersion
+	}
+	if version != LatestVersion {
+		if n, err := strconv.Atoi(version); err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid schema version %q (expected a positive number or %s)", version, LatestVersion)
+		}
+	}
+
+	cacheKey := subject + "/" + version
+	r.mu.Lock()
+	schema, ok := r.subjects[cacheKey]
+	r.mu.Unlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema = &Schema{}
+	path := fmt.Sprintf("/subjects/%s/versions/%s", url.PathEscape(subject), version)
+	if err := r.do(ctx, http.MethodGet, path, nil, schema); err != nil {
+		return nil, fmt.Errorf("failed to get version %s of subject %s: %w", version, subject, err)
+	}
+
+	r.mu.Lock()
+	r.subjects[cacheKey] = schema
+	if schema.ID > 0 {
+		r.byID[schema.ID] = schema
+	}
+	r.mu.Unlock()
+	return schema, nil
+}
+
+// resolveReferences fetches every schema referenced by schema, directly or not,
+// keyed by reference name
+func (r *SchemaRegistry) resolveReferences(ctx context.Context, schema *Schema) (map[string]*Schema, error) {
+	resolved := make(map[string]*Schema)
+	var resolve func(refs []SchemaReference) error
+	resolve = func(refs []SchemaReference) error {
+		for _, ref := range refs {
+			if _, ok := resolved[ref.Name]; ok {
+				continue
+			}
+			refSchema, err := r.SubjectSchema(ctx, ref.Subject, strconv.Itoa(ref.Version))
+			if err != nil {
+				return fmt.Errorf("failed to resolve reference %s: %w", ref.Name, err)
+			}
+			resolved[ref.Name] = refSchema
+			if err := resolve(refSchema.References); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := resolve(schema.References); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// do sends a request to the registry and decodes the JSON response into out
+func (r *SchemaRegistry) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json, application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	}
+	switch {
+	case r.token != "":
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	case r.username != "":
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		regErr := &RegistryError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(data, regErr)
+		return regErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid schema registry response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CompatibilityLevels lists the compatibility levels of the Schema Registry
+var CompatibilityLevels = []string{
+	"BACKWARD", "BACKWARD_TRANSITIVE",
+	"FORWARD", "FORWARD_TRANSITIVE",
+	"FULL", "FULL_TRANSITIVE",
+	"NONE",
+}
+
+// Registry error codes for subjects and versions that were already soft deleted
+const (
+	errCodeSubjectSoftDeleted = 40404
+	errCodeVersionSoftDeleted = 40406
+)
+
+// CompatibilityResult is the result of a compatibility check.
+// Messages explain why a schema is incompatible.
+type CompatibilityResult struct {
+	Compatible bool     `json:"is_compatible"`
+	Messages   []string `json:"messages,omitempty"`
+}
+
+// ParseCompatibilityLevel validates a compatibility level, case insensitively
+func ParseCompatibilityLevel(level string) (string, error) {
+	upper := strings.ToUpper(strings.TrimSpace(level))
+	for _, l := range CompatibilityLevels {
+		if upper == l {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported compatibility level %q (expected one of %s)", level, strings.Join(CompatibilityLevels, ", "))
+}
+
+// Subjects lists the registered subjects, including soft deleted ones when deleted is true
+func (r *SchemaRegistry) Subjects(ctx context.Context, deleted bool) ([]string, error) {
+	var subjects []string
+	if err := r.do(ctx, http.MethodGet, "/subjects"+deletedQuery(deleted), nil, &subjects); err != nil {
+		return nil, fmt.Errorf("failed to list subjects: %w", err)
+	}
+	return subjects, nil
+}
+
+// Versions lists the versions of subject, including soft deleted ones when deleted is true
+func (r *SchemaRegistry) Versions(ctx context.Context, subject string, deleted bool) ([]int, error) {
+	var versions []int
+	path := fmt.Sprintf("/subjects/%s/versions%s", url.PathEscape(subject), deletedQuery(deleted))
+	if err := r.do(ctx, http.MethodGet, path, nil, &versions); err != nil {
+		return nil, fmt.Errorf("failed to list versions of subject %s: %w", subject, err)
+	}
+	return versions, nil
+}
+
+// RegisterSchema registers schema under subject and returns its ID. Registering
+// a schema that already exists returns the existing ID.
+func (r *SchemaRegistry) RegisterSchema(ctx context.Context, subject string, schema Schema) (int, error) {
+	var resp struct {
+		ID int `json:"id"`
+	}
+	body := Schema{SchemaType: schemaTypeField(schema.SchemaType), Schema: schema.Schema, References: schema.References}
+	path := fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject))
+	if err := r.do(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return 0, fmt.Errorf("failed to register schema under subject %s: %w", subject, err)
+	}
+	return resp.ID, nil
+}
+
+// CheckCompatibility tests schema against a version of subject, or its latest version
+func (r *SchemaRegistry) CheckCompatibility(ctx context.Context, subject, version string, schema Schema) (CompatibilityResult, error) {
+	if version == "" {
+		version = LatestVersion
+	}
+	var result CompatibilityResult
+	body := Schema{SchemaType: schemaTypeField(schema.SchemaType), Schema: schema.Schema, References: schema.References}
+	path := fmt.Sprintf("/compatibility/subjects/%s/versions/%s?verbose=true", url.PathEscape(subject), url.PathEscape(version))
+	if err := r.do(ctx, http.MethodPost, path, body, &result); err != nil {
+		return result, fmt.Errorf("failed to check compatibility with subject %s: %w", subject, err)
+	}
+	return result, nil
+}
+
+// Compatibility returns the compatibility level of subject, falling back to the
+// global level, or the global level when subject is empty
+func (r *SchemaRegistry) Compatibility(ctx context.Context, subject string) (string, error) {
+	var resp struct {
+		CompatibilityLevel string `json:"compatibilityLevel"`
+	}
+	path := "/config"
+	if subject != "" {
+		path += "/" + url.PathEscape(subject) + "?defaultToGlobal=true"
+	}
+	if err := r.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to get compatibility level: %w", err)
+	}
+	return resp.CompatibilityLevel, nil
+}
+
+// SetCompatibility sets the compatibility level of subject, or the global level when subject is empty
+func (r *SchemaRegistry) SetCompatibility(ctx context.Context, subject, level string) (string, error) {
+	level, err := ParseCompatibilityLevel(level)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Compatibility string `json:"compatibility"`
+	}
+	path := "/config"
+	if subject != "" {
+		path += "/" + url.PathEscape(subject)
+	}
+	body := map[string]string{"compatibility": level}
+	if err := r.do(ctx, http.MethodPut, path, body, &resp); err != nil {
+		return "", fmt.Errorf("failed to set compatibility level: %w", err)
+	}
+	return resp.Compatibility, nil
+}
+
+// DeleteSubject deletes every version of subject and returns the deleted versions.
+// A permanent (hard) delete soft deletes the subject first, as the registry requires.
+func (r *SchemaRegistry) DeleteSubject(ctx context.Context, subject string, permanent bool) ([]int, error) {
+	path := "/subjects/" + url.PathEscape(subject)
+
+	var versions []int
+	err := r.do(ctx, http.MethodDelete, path, nil, &versions)
+	if err != nil && !(permanent && isRegistryErrorCode(err, errCodeSubjectSoftDeleted)) {
+		return nil, fmt.Errorf("failed to delete subject %s: %w", subject, err)
+	}
+	if permanent {
+		if err := r.do(ctx, http.MethodDelete, path+"?permanent=true", nil, &versions); err != nil {
+			return nil, fmt.Errorf("failed to permanently delete subject %s: %w", subject, err)
+		}
+	}
+	r.forgetSubject(subject)
+	return versions, nil
+}
+
+// DeleteVersion deletes a version of subject, or its latest version, and returns
+// the deleted version number. A permanent delete soft deletes the version first.
+func (r *SchemaRegistry) DeleteVersion(ctx context.Context, subject, version string, permanent bool) (int, error) {
+	if version == "" {
+		version = LatestVersion
+	}
+	if permanent && version == LatestVersion {
+		// latest moves once the version is soft deleted, pin it first
+		latest, err := r.SubjectSchema(ctx, subject, LatestVersion)
+		if err != nil {
+			return 0, err
+		}
+		version = strconv.Itoa(latest.Version)
+	}
+	path := fmt.Sprintf("/subjects/%s/versions/%s", url.PathEscape(subject), url.PathEscape(version))
+
+	var deleted int
+	err := r.do(ctx, http.MethodDelete, path, nil, &deleted)
+	if err != nil && !(permanent && isRegistryErrorCode(err, errCodeVersionSoftDeleted)) {
+		return 0, fmt.Errorf("failed to delete version %s of subject %s: %w", version, subject, err)
+	}
+	if permanent {
+		if err := r.do(ctx, http.MethodDelete, path+"?permanent=true", nil, &deleted); err != nil {
+			return 0, fmt.Errorf("failed to permanently delete version %s of subject %s: %w", version, subject, err)
+		}
+	}
+	r.forgetSubject(subject)
+	return deleted, nil
+}
+
+// forgetSubject drops the cached versions of subject after a change
+func (r *SchemaRegistry) forgetSubject(subject string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.subjects {
+		if strings.HasPrefix(key, subject+"/") {
+			delete(r.subjects, key)
+		}
+	}
+}
+
+// deletedQuery returns the query string listing soft deleted subjects or versions
+func deletedQuery(deleted bool) string {
+	if !deleted {
+		return ""
+	}
+	return "?deleted=true"
+}
+
+// schemaTypeField returns the schemaType to send; Avro is the registry default and omitted
+func schemaTypeField(schemaType string) string {
+	if strings.EqualFold(schemaType, SchemaTypeAvro) {
+		return ""
+	}
+	return strings.ToUpper(schemaType)
+}
+
+func isRegistryErrorCode(err error, code int) bool {
+	var regErr *RegistryError
+	return errors.As(err, &regErr) && regErr.Code == code
+}
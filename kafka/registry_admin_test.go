@@ -0,0 +1,183 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// recordedRequest is a request received by a recording registry
+type recordedRequest struct {
+	Method string
+	URI    string
+	Body   string
+}
+
+// newRecordingRegistry answers each request with the response registered for
+// "METHOD URI" and records every request
+func newRecordingRegistry(t *testing.T, responses map[string]string) (*SchemaRegistry, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, recordedRequest{Method: r.Method, URI: r.URL.RequestURI(), Body: string(body)})
+		resp, ok := responses[r.Method+" "+r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code":40401,"message":"Subject not found"}`))
+			return
+		}
+		if resp == "soft-deleted" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code":40404,"message":"Subject 'orders-value' was soft deleted"}`))
+			return
+		}
+		w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewSchemaRegistry(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, &requests
+}
+
+func TestRegisterSchemaSendsTypeAndReferences(t *testing.T) {
+	client, requests := newRecordingRegistry(t, map[string]string{
+		"POST /subjects/orders-value/versions": `{"id":12}`,
+	})
+
+	id, err := client.RegisterSchema(context.Background(), "orders-value", Schema{
+		SchemaType: "protobuf",
+		Schema:     `syntax = "proto3";`,
+		References: []SchemaReference{{Name: "customer.proto", Subject: "customer", Version: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 12 {
+		t.Fatalf("expected ID 12, got %d", id)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal([]byte((*requests)[0].Body), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["schemaType"] != SchemaTypeProtobuf || body["references"] == nil {
+		t.Fatalf("unexpected request body %s", (*requests)[0].Body)
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	client, _ := newRecordingRegistry(t, map[string]string{
+		"POST /compatibility/subjects/orders-value/versions/latest?verbose=true": `{"is_compatible":false,"messages":["READER_FIELD_MISSING_DEFAULT_VALUE"]}`,
+	})
+
+	result, err := client.CheckCompatibility(context.Background(), "orders-value", "", Schema{Schema: `"string"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Compatible || len(result.Messages) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestCompatibilityLevels(t *testing.T) {
+	client, requests := newRecordingRegistry(t, map[string]string{
+		"GET /config": `{"compatibilityLevel":"BACKWARD"}`,
+		"GET /config/orders-value?defaultToGlobal=true": `{"compatibilityLevel":"FULL"}`,
+		"PUT /config/orders-value":                      `{"compatibility":"FORWARD_TRANSITIVE"}`,
+	})
+	ctx := context.Background()
+
+	if level, err := client.Compatibility(ctx, ""); err != nil || level != "BACKWARD" {
+		t.Fatalf("global compatibility = %q, %v", level, err)
+	}
+	if level, err := client.Compatibility(ctx, "orders-value"); err != nil || level != "FULL" {
+		t.Fatalf("subject compatibility = %q, %v", level, err)
+	}
+	if level, err := client.SetCompatibility(ctx, "orders-value", "forward_transitive"); err != nil || level != "FORWARD_TRANSITIVE" {
+		t.Fatalf("SetCompatibility() = %q, %v", level, err)
+	}
+	if last := (*requests)[len(*requests)-1]; last.Body != `{"compatibility":"FORWARD_TRANSITIVE"}` {
+		t.Fatalf("unexpected request body %s", last.Body)
+	}
+
+	if _, err := client.SetCompatibility(ctx, "orders-value", "SOMETIMES"); err == nil {
+		t.Fatalf("expected an invalid level error")
+	}
+}
+
+func TestDeleteSubjectPermanentSoftDeletesFirst(t *testing.T) {
+	client, requests := newRecordingRegistry(t, map[string]string{
+		"DELETE /subjects/orders-value":                `[1,2]`,
+		"DELETE /subjects/orders-value?permanent=true": `[1,2]`,
+	})
+
+	versions, err := client.DeleteSubject(context.Background(), "orders-value", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(versions, []int{1, 2}) {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+	var uris []string
+	for _, r := range *requests {
+		uris = append(uris, r.Method+" "+r.URI)
+	}
+	want := []string{"DELETE /subjects/orders-value", "DELETE /subjects/orders-value?permanent=true"}
+	if !reflect.DeepEqual(uris, want) {
+		t.Fatalf("requests = %v, want %v", uris, want)
+	}
+}
+
+func TestDeleteSubjectPermanentAfterSoftDelete(t *testing.T) {
+	client, _ := newRecordingRegistry(t, map[string]string{
+		"DELETE /subjects/orders-value":                "soft-deleted",
+		"DELETE /subjects/orders-value?permanent=true": `[1]`,
+	})
+	if _, err := client.DeleteSubject(context.Background(), "orders-value", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// A soft delete of an already soft deleted subject is an error
+	if _, err := client.DeleteSubject(context.Background(), "orders-value", false); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestDeleteLatestVersionPermanentPinsVersion(t *testing.T) {
+	client, _ := newRecordingRegistry(t, map[string]string{
+		"GET /subjects/orders-value/versions/latest":              `{"subject":"orders-value","version":3,"id":9,"schema":"\"string\""}`,
+		"DELETE /subjects/orders-value/versions/3":                `3`,
+		"DELETE /subjects/orders-value/versions/3?permanent=true": `3`,
+	})
+	version, err := client.DeleteVersion(context.Background(), "orders-value", LatestVersion, true)
+	if err != nil || version != 3 {
+		t.Fatalf("DeleteVersion() = %d, %v", version, err)
+	}
+}
+
+func TestSubjectsAndVersions(t *testing.T) {
+	client, _ := newRecordingRegistry(t, map[string]string{
+		"GET /subjects?deleted=true":                  `["a-value","b-value"]`,
+		"GET /subjects/a-value/versions":              `[1,2,3]`,
+		"GET /subjects/a-value/versions?deleted=true": `[1,2,3,4]`,
+	})
+	ctx := context.Background()
+
+	if subjects, err := client.Subjects(ctx, true); err != nil || len(subjects) != 2 {
+		t.Fatalf("Subjects() = %v, %v", subjects, err)
+	}
+	if versions, err := client.Versions(ctx, "a-value", false); err != nil || len(versions) != 3 {
+		t.Fatalf("Versions() = %v, %v", versions, err)
+	}
+	if versions, err := client.Versions(ctx, "a-value", true); err != nil || len(versions) != 4 {
+		t.Fatalf("Versions(deleted) = %v, %v", versions, err)
+	}
+}
@@ -0,0 +1,298 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testAvroSchema = `{"type":"record","name":"Order","namespace":"shop","fields":[
+	{"name":"id","type":"long"},
+	{"name":"note","type":["null","string"],"default":null}]}`
+
+const testProtobufSchema = `syntax = "proto3";
+package shop;
+message Order {
+  int64 id = 1;
+  string customer_name = 2;
+  message Line { string sku = 1; }
+}
+message Refund { int64 order_id = 1; }`
+
+const testJSONSchema = `{"type":"object","properties":{"id":{"type":"integer"}},"required":["id"]}`
+
+// testRegistry is a stand-in Schema Registry serving fixed schemas by ID and by subject version
+type testRegistry struct {
+	*httptest.Server
+	schemas  map[int]*Schema
+	requests atomic.Int32
+	auth     string
+}
+
+func newTestRegistry(t *testing.T, schemas ...*Schema) *testRegistry {
+	t.Helper()
+	reg := &testRegistry{schemas: make(map[int]*Schema)}
+	for _, s := range schemas {
+		reg.schemas[s.ID] = s
+	}
+	reg.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reg.requests.Add(1)
+		reg.auth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+			for _, s := range reg.schemas {
+				if parts[2] == itoa(s.ID) {
+					json.NewEncoder(w).Encode(Schema{Schema: s.Schema, SchemaType: s.SchemaType, References: s.References})
+					return
+				}
+			}
+		case len(parts) == 4 && parts[0] == "subjects" && parts[2] == "versions":
+			var found *Schema
+			for _, s := range reg.schemas {
+				if s.Subject == parts[1] && (parts[3] == itoa(s.Version) || parts[3] == LatestVersion && (found == nil || s.Version > found.Version)) {
+					found = s
+				}
+			}
+			if found != nil {
+				json.NewEncoder(w).Encode(found)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`))
+	}))
+	t.Cleanup(reg.Close)
+	return reg
+}
+
+func itoa(n int) string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
+
+func TestParseWireFormat(t *testing.T) {
+	data := AppendWireFormat(42, []byte("payload"))
+	id, payload, ok := ParseWireFormat(data)
+	if !ok || id != 42 || string(payload) != "payload" {
+		t.Fatalf("ParseWireFormat() = %d, %q, %v", id, payload, ok)
+	}
+
+	for _, data := range [][]byte{nil, []byte(`{"id":1}`), {0, 0, 0, 0}, {0, 0, 0, 0, 0, 1}} {
+		if _, _, ok := ParseWireFormat(data); ok {
+			t.Errorf("ParseWireFormat(%v) should not match", data)
+		}
+	}
+}
+
+func TestSchemaRegistryAvroRoundTrip(t *testing.T) {
+	reg := newTestRegistry(t, &Schema{ID: 7, Subject: "orders-value", Version: 2, Schema: testAvroSchema})
+	client, err := NewSchemaRegistry(reg.URL, WithRegistryBasicAuth("user", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	schema, err := client.SubjectSchema(ctx, "orders-value", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := client.Encode(ctx, schema, []byte(`{"id": 12, "note": "gift"}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _, ok := ParseWireFormat(encoded); !ok || id != 7 {
+		t.Fatalf("expected wire format with schema 7, got %v", encoded)
+	}
+
+	decoded, err := client.Decode(ctx, encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.SchemaID != 7 || string(decoded.Data) != `{"id":12,"note":"gift"}` || decoded.MessageType != "" {
+		t.Fatalf("Decode() = %+v", decoded)
+	}
+
+	// Everything is cached after the subject lookup
+	if _, err := client.Decode(ctx, encoded); err != nil {
+		t.Fatal(err)
+	}
+	if n := reg.requests.Load(); n != 1 {
+		t.Fatalf("expected 1 registry request, got %d", n)
+	}
+	if !strings.HasPrefix(reg.auth, "Basic ") {
+		t.Fatalf("expected basic auth, got %q", reg.auth)
+	}
+}
+
+func TestSchemaRegistryAvroReferences(t *testing.T) {
+	customer := &Schema{ID: 1, Subject: "customer", Version: 1,
+		Schema: `{"type":"record","name":"Customer","namespace":"shop","fields":[{"name":"name","type":"string"}]}`}
+	order := &Schema{ID: 2, Subject: "orders-value", Version: 1,
+		Schema: `{"type":"record","name":"Order","namespace":"shop","fields":[
+			{"name":"buyer","type":"shop.Customer"},{"name":"seller","type":"shop.Customer"}]}`,
+		References: []SchemaReference{{Name: "shop.Customer", Subject: "customer", Version: 1}}}
+	reg := newTestRegistry(t, customer, order)
+	client, err := NewSchemaRegistry(reg.URL, WithRegistryBearerToken("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := `{"buyer":{"name":"ann"},"seller":{"name":"bob"}}`
+	encoded, err := client.Encode(context.Background(), order, []byte(doc), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := client.Decode(context.Background(), encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded.Data) != doc {
+		t.Fatalf("Decode() = %s", decoded.Data)
+	}
+	if reg.auth != "Bearer token" {
+		t.Fatalf("expected bearer auth, got %q", reg.auth)
+	}
+}
+
+func TestSchemaRegistryProtobuf(t *testing.T) {
+	reg := newTestRegistry(t, &Schema{ID: 3, Subject: "orders-value", Version: 1, SchemaType: SchemaTypeProtobuf, Schema: testProtobufSchema})
+	client, err := NewSchemaRegistry(reg.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	schema, err := client.SubjectSchema(ctx, "orders-value", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := client.Encode(ctx, schema, []byte(`{"id": "5", "customer_name": "ann"}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := client.Decode(ctx, encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(decoded.Data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["id"] != "5" || got["customer_name"] != "ann" || decoded.MessageType != "shop.Order" {
+		t.Fatalf("Decode() = %s (%s)", decoded.Data, decoded.MessageType)
+	}
+
+	// Message indexes [1]: the second top level message, Refund
+	refund := AppendWireFormat(3, []byte{0x02, 0x02, 0x08, 0x09})
+	decoded, err = client.Decode(ctx, refund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded.Data) != `{"order_id":"9"}` || decoded.MessageType != "shop.Refund" {
+		t.Fatalf("Decode(refund) = %s (%s)", decoded.Data, decoded.MessageType)
+	}
+}
+
+func TestSchemaRegistryProtobufMessageType(t *testing.T) {
+	reg := newTestRegistry(t, &Schema{ID: 3, Subject: "orders-value", Version: 1, SchemaType: SchemaTypeProtobuf, Schema: testProtobufSchema})
+	client, err := NewSchemaRegistry(reg.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	schema := &Schema{ID: 3}
+
+	tests := []struct {
+		messageType string
+		data        string
+		indexes     []byte
+		want        string
+	}{
+		{"shop.Refund", `{"order_id": "9"}`, []byte{0x02, 0x02}, "shop.Refund"},
+		{"Refund", `{"order_id": "9"}`, []byte{0x02, 0x02}, "shop.Refund"},
+		{"shop.Order.Line", `{"sku": "a1"}`, []byte{0x04, 0x00, 0x00}, "shop.Order.Line"},
+		{"", `{"id": "5"}`, []byte{0x00}, "shop.Order"},
+	}
+	for _, tt := range tests {
+		encoded, err := client.Encode(ctx, schema, []byte(tt.data), tt.messageType)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.messageType, err)
+		}
+		if _, payload, _ := ParseWireFormat(encoded); !bytes.HasPrefix(payload, tt.indexes) {
+			t.Fatalf("%q: expected message indexes %v, got %v", tt.messageType, tt.indexes, payload)
+		}
+		decoded, err := client.Decode(ctx, encoded)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.messageType, err)
+		}
+		if decoded.MessageType != tt.want {
+			t.Fatalf("%q: decoded as %s", tt.messageType, decoded.MessageType)
+		}
+	}
+
+	if _, err := client.Encode(ctx, schema, []byte(`{}`), "shop.Missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
+
+func TestSchemaRegistryJSONSchema(t *testing.T) {
+	reg := newTestRegistry(t, &Schema{ID: 4, Subject: "events-value", Version: 1, SchemaType: SchemaTypeJSON, Schema: testJSONSchema})
+	client, err := NewSchemaRegistry(reg.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	schema, err := client.SubjectSchema(ctx, "events-value", LatestVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := client.Encode(ctx, schema, []byte(`{ "id": 1 }`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := client.Decode(ctx, encoded)
+	if err != nil || string(decoded.Data) != `{"id":1}` {
+		t.Fatalf("Decode() = %s, %v", decoded.Data, err)
+	}
+
+	if _, err := client.Encode(ctx, schema, []byte(`{"id": "one"}`), ""); err == nil {
+		t.Fatalf("expected a validation error")
+	}
+	if _, err := client.Encode(ctx, schema, []byte(`{"id": 1}`), "shop.Order"); err == nil {
+		t.Fatalf("expected a message type error for JSON Schema")
+	}
+}
+
+func TestSchemaRegistryNotFound(t *testing.T) {
+	reg := newTestRegistry(t)
+	client, err := NewSchemaRegistry(reg.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Decode(context.Background(), AppendWireFormat(99, nil))
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Schema not found") {
+		t.Fatalf("expected the registry message in %q", err)
+	}
+}
+
+func TestNewSchemaRegistryRequiresURL(t *testing.T) {
+	if _, err := (&Config{}).NewSchemaRegistry(); err != ErrSchemaRegistryNotConfigured {
+		t.Fatalf("expected ErrSchemaRegistryNotConfigured, got %v", err)
+	}
+	if _, err := NewSchemaRegistry("localhost:8081"); err == nil {
+		t.Fatalf("expected an error for a URL without scheme")
+	}
+}
@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/oauth"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// Supported SASL mechanisms for KAFKA_SASL_MECHANISM
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+	SASLOAuthBearer = "OAUTHBEARER"
+)
+
+// SASLMechanisms lists the supported SASL mechanisms
+var SASLMechanisms = []string{SASLPlain, SASLScramSHA256, SASLScramSHA512, SASLOAuthBearer}
+
+// ParseSASLMechanism validates a SASL mechanism name, case insensitively.
+// An empty name disables SASL.
+func ParseSASLMechanism(name string) (string, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" {
+		return "", nil
+	}
+	for _, m := range SASLMechanisms {
+		if name == m || strings.ReplaceAll(name, "_", "-") == m {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported SASL mechanism %q (expected one of %s)", name, strings.Join(SASLMechanisms, ", "))
+}
+
+// loadSASLConfig reads the SASL settings into cfg from the environment or the active profile
+func loadSASLConfig(cfg *Config) error {
+	mechanism, err := ParseSASLMechanism(getSetting("KAFKA_SASL_MECHANISM"))
+	if err != nil {
+		return fmt.Errorf("invalid KAFKA_SASL_MECHANISM: %w", err)
+	}
+	if mechanism == "" {
+		return nil
+	}
+	if cfg.UseAWSIAM {
+		return fmt.Errorf("KAFKA_SASL_MECHANISM %s cannot be combined with AWS MSK IAM authentication (set KAFKA_USE_AWS_IAM=false)", mechanism)
+	}
+
+	cfg.SASLMechanism = mechanism
+	if mechanism == SASLOAuthBearer {
+		return loadOAuthConfig(cfg)
+	}
+
+	if cfg.SASLUsername, err = getSecret("KAFKA_SASL_USERNAME"); err != nil {
+		return err
+	}
+	if cfg.SASLUsername == "" {
+		return fmt.Errorf("KAFKA_SASL_USERNAME is required with KAFKA_SASL_MECHANISM %s", mechanism)
+	}
+
+	if cfg.saslPassword, err = getSecret("KAFKA_SASL_PASSWORD"); err != nil {
+		return err
+	}
+	cfg.saslPasswordFile = expandHome(strings.TrimSpace(getSetting("KAFKA_SASL_PASSWORD_FILE")))
+	switch {
+	case cfg.saslPassword != "" && cfg.saslPasswordFile != "":
+		return fmt.Errorf("set only one of KAFKA_SASL_PASSWORD and KAFKA_SASL_PASSWORD_FILE")
+	case cfg.saslPassword == "" && cfg.saslPasswordFile == "":
+		return fmt.Errorf("KAFKA_SASL_PASSWORD or KAFKA_SASL_PASSWORD_FILE is required with KAFKA_SASL_MECHANISM %s", mechanism)
+	}
+
+	// Read the file once now so a wrong path fails before connecting
+	_, err = cfg.readSASLPassword()
+	return err
+}
+
+// readSASLPassword returns the SASL password. A password file is read on every
+// call so rotated secrets are picked up on reconnect.
+func (c *Config) readSASLPassword() (string, error) {
+	if c.saslPasswordFile == "" {
+		return c.saslPassword, nil
+	}
+	data, err := os.ReadFile(c.saslPasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read KAFKA_SASL_PASSWORD_FILE %q: %w", c.saslPasswordFile, err)
+	}
+	password := strings.TrimRight(string(data), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("KAFKA_SASL_PASSWORD_FILE %q is empty", c.saslPasswordFile)
+	}
+	return password, nil
+}
+
+// saslMechanism returns the franz-go mechanism of the configured SASL mechanism
+func (c *Config) saslMechanism() sasl.Mechanism {
+	switch c.SASLMechanism {
+	case SASLPlain:
+		return plain.Plain(func(context.Context) (plain.Auth, error) {
+			password, err := c.readSASLPassword()
+			return plain.Auth{User: c.SASLUsername, Pass: password}, err
+		})
+	case SASLScramSHA256, SASLScramSHA512:
+		auth := func(context.Context) (scram.Auth, error) {
+			password, err := c.readSASLPassword()
+			return scram.Auth{User: c.SASLUsername, Pass: password}, err
+		}
+		if c.SASLMechanism == SASLScramSHA256 {
+			return scram.Sha256(auth)
+		}
+		return scram.Sha512(auth)
+	case SASLOAuthBearer:
+		return oauth.Oauth(func(ctx context.Context) (oauth.Auth, error) {
+			token, err := c.oauthTokens.Token(ctx)
+			return oauth.Auth{Token: token, Extensions: c.oauthExtensions}, err
+		})
+	default:
+		return nil
+	}
+}
+
+// expandHome replaces a leading ~/ with the home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
@@ -0,0 +1,109 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSASLMechanism(t *testing.T) {
+	tests := map[string]string{
+		"":              "",
+		"plain":         SASLPlain,
+		"scram-sha-256": SASLScramSHA256,
+		"SCRAM_SHA_512": SASLScramSHA512,
+	}
+	for in, want := range tests {
+		got, err := ParseSASLMechanism(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSASLMechanism(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseSASLMechanism("GSSAPI"); err == nil {
+		t.Fatalf("expected an unsupported mechanism error")
+	}
+}
+
+func TestNewConfigSASLWithPasswordFileAndTLS(t *testing.T) {
+	clearSettingsEnv(t)
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KAFKA_BROKERS", "kafka-1.internal:9093")
+	t.Setenv("KAFKA_SASL_MECHANISM", "scram-sha-512")
+	t.Setenv("KAFKA_SASL_USERNAME", "app")
+	t.Setenv("KAFKA_SASL_PASSWORD_FILE", passwordFile)
+	t.Setenv("KAFKA_TLS_CA_FILE", writeTestCA(t))
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SASLMechanism != SASLScramSHA512 || !cfg.TLSEnabled || cfg.tlsConfig.RootCAs == nil {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if password, err := cfg.readSASLPassword(); err != nil || password != "s3cret" {
+		t.Fatalf("readSASLPassword() = %q, %v", password, err)
+	}
+	if name := cfg.saslMechanism().Name(); name != SASLScramSHA512 {
+		t.Fatalf("mechanism name = %q", name)
+	}
+}
+
+func TestNewConfigSASLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"missing username", map[string]string{"KAFKA_SASL_MECHANISM": "PLAIN", "KAFKA_SASL_PASSWORD": "x"}},
+		{"missing password", map[string]string{"KAFKA_SASL_MECHANISM": "PLAIN", "KAFKA_SASL_USERNAME": "app"}},
+		{"password and file", map[string]string{"KAFKA_SASL_MECHANISM": "PLAIN", "KAFKA_SASL_USERNAME": "app", "KAFKA_SASL_PASSWORD": "x", "KAFKA_SASL_PASSWORD_FILE": "/x"}},
+		{"missing password file", map[string]string{"KAFKA_SASL_MECHANISM": "PLAIN", "KAFKA_SASL_USERNAME": "app", "KAFKA_SASL_PASSWORD_FILE": "/does/not/exist"}},
+		{"unknown mechanism", map[string]string{"KAFKA_SASL_MECHANISM": "KERBEROS"}},
+		{"with AWS IAM", map[string]string{"KAFKA_SASL_MECHANISM": "PLAIN", "KAFKA_SASL_USERNAME": "app", "KAFKA_SASL_PASSWORD": "x", "KAFKA_USE_AWS_IAM": "true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearSettingsEnv(t)
+			t.Setenv("KAFKA_TLS_ENABLED", "false")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, err := NewConfig(); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestNewConfigSCRAMOnMSKSkipsIAMDetection(t *testing.T) {
+	clearSettingsEnv(t)
+	t.Setenv("KAFKA_BROKERS", "b-1.prod.kafka.eu-west-1.amazonaws.com:9096")
+	t.Setenv("KAFKA_SASL_MECHANISM", SASLScramSHA512)
+	t.Setenv("KAFKA_SASL_USERNAME", "app")
+	t.Setenv("KAFKA_SASL_PASSWORD", "secret")
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.UseAWSIAM || cfg.SASLMechanism != SASLScramSHA512 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
+
+func TestNewConfigSASLFromProfile(t *testing.T) {
+	clearSettingsEnv(t)
+	t.Setenv("KAFKA_TLS_ENABLED", "false")
+	UseProfile(&Profile{Name: "onprem", SASL: &ProfileSASL{Mechanism: "plain", Username: "app", Password: "secret"}})
+	t.Cleanup(func() { UseProfile(nil) })
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SASLMechanism != SASLPlain || cfg.SASLUsername != "app" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
@@ -0,0 +1,153 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret references, accepted by secret settings in place of the value
+const (
+	secretExecPrefix = "exec:"
+	secretFilePrefix = "file://"
+	secretEnvPrefix  = "env:"
+)
+
+// SecretSettingEnv tells a credential helper which setting it resolves
+const SecretSettingEnv = "KAFKA_CLI_SECRET"
+
+// secretHelperTimeout bounds a credential helper run, which may prompt for a login
+const secretHelperTimeout = 2 * time.Minute
+
+// Resolved secrets, cached for the process by setting and reference
+var (
+	secretMu    sync.Mutex
+	secretCache = make(map[string]string)
+)
+
+// IsSecretReference reports whether value refers to a secret instead of holding it
+func IsSecretReference(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, secretExecPrefix) ||
+		strings.HasPrefix(value, secretFilePrefix) ||
+		strings.HasPrefix(value, secretEnvPrefix)
+}
+
+// getSecret returns the setting key like getSetting, resolving it first when
+// it is a secret reference:
+//
+//	exec:<command>   stdout of the command, run by the shell
+//	file://<path>    content of the file
+//	env:<NAME>       value of the environment variable NAME
+//
+// References are not resolved when they come from .env, see resolveSecretSetting.
+// Errors never include the resolved value.
+func getSecret(key string) (string, error) {
+	return resolveSecretSetting(key, getSetting(key))
+}
+
+// resolveSecretSetting returns value, read from the setting key, resolved when
+// it is a secret reference. A reference loaded from .env is an error: the file
+// may come with any repository or shared directory, and would otherwise run
+// commands or send local files and variables to its brokers.
+func resolveSecretSetting(key, value string) (string, error) {
+	if !IsSecretReference(value) {
+		return value, nil
+	}
+	if isDotEnvSetting(key, value) {
+		return "", fmt.Errorf("failed to resolve %s: secret references are not allowed in .env, export the variable or use a profile", key)
+	}
+	ref := strings.TrimSpace(value)
+
+	secretMu.Lock()
+	defer secretMu.Unlock()
+
+	cacheKey := key + "=" + ref
+	if secret, ok := secretCache[cacheKey]; ok {
+		return secret, nil
+	}
+	secret, err := resolveSecret(key, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", key, err)
+	}
+	secretCache[cacheKey] = secret
+	return secret, nil
+}
+
+// resolveSecret returns the secret ref refers to
+func resolveSecret(key, ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, secretExecPrefix):
+		return runCredentialHelper(key, strings.TrimSpace(strings.TrimPrefix(ref, secretExecPrefix)))
+	case strings.HasPrefix(ref, secretFilePrefix):
+		path := expandHome(strings.TrimPrefix(ref, secretFilePrefix))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		secret := strings.TrimRight(string(data), "\r\n")
+		if secret == "" {
+			return "", fmt.Errorf("secret file %q is empty", path)
+		}
+		return secret, nil
+	default:
+		name := strings.TrimSpace(strings.TrimPrefix(ref, secretEnvPrefix))
+		if name == "" {
+			return "", fmt.Errorf("env: reference without a variable name")
+		}
+		secret := os.Getenv(name)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	}
+}
+
+// runCredentialHelper runs command with the shell and returns its output, the
+// trailing newline removed. The helper gets the setting name in
+// KAFKA_CLI_SECRET and shares the terminal for stdin and stderr, so it can
+// prompt for a login.
+func runCredentialHelper(key, command string) (string, error) {
+	if command == "" {
+		return "", fmt.Errorf("exec: reference without a command")
+	}
+	// Only the program name is reported, arguments may be sensitive
+	program := strings.Fields(command)[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretHelperTimeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), SecretSettingEnv+"="+key)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("credential helper %s timed out after %s", program, secretHelperTimeout)
+		}
+		return "", fmt.Errorf("credential helper %s failed: %w", program, err)
+	}
+	secret := strings.TrimRight(stdout.String(), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("credential helper %s printed nothing", program)
+	}
+	return secret, nil
+}
+
+// shellCommand returns a command running command with the system shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
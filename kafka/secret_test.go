@@ -0,0 +1,158 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGetSecretReferences(t *testing.T) {
+	clearSettingsEnv(t)
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_VAULT_TOKEN", "from-env")
+
+	tests := map[string]string{
+		"plain": "plain-secret",
+		"file":  "file://" + path,
+		"env":   "env:TEST_VAULT_TOKEN",
+	}
+	want := map[string]string{"plain": "plain-secret", "file": "from-file", "env": "from-env"}
+	for name, value := range tests {
+		t.Setenv("KAFKA_SASL_PASSWORD", value)
+		secret, err := getSecret("KAFKA_SASL_PASSWORD")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if secret != want[name] {
+			t.Fatalf("%s: secret = %q, want %q", name, secret, want[name])
+		}
+	}
+
+	for _, value := range []string{"file://" + path + ".missing", "env:TEST_UNSET_SECRET", "env:", "exec:"} {
+		t.Setenv("KAFKA_SASL_PASSWORD", value)
+		if _, err := getSecret("KAFKA_SASL_PASSWORD"); err == nil || !strings.Contains(err.Error(), "failed to resolve KAFKA_SASL_PASSWORD") {
+			t.Fatalf("%s: expected a resolve error, got %v", value, err)
+		}
+	}
+}
+
+func TestGetSecretCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the helper below needs sh")
+	}
+	clearSettingsEnv(t)
+	calls := filepath.Join(t.TempDir(), "calls")
+	t.Setenv("KAFKA_SASL_OAUTH_CLIENT_SECRET", `exec:echo run >> `+calls+`; echo "$KAFKA_CLI_SECRET-value"`)
+
+	for i := 0; i < 2; i++ {
+		secret, err := getSecret("KAFKA_SASL_OAUTH_CLIENT_SECRET")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret != "KAFKA_SASL_OAUTH_CLIENT_SECRET-value" {
+			t.Fatalf("secret = %q", secret)
+		}
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "run"); n != 1 {
+		t.Fatalf("helper ran %d times, want once", n)
+	}
+}
+
+func TestGetSecretCredentialHelperErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the helpers below need sh")
+	}
+	clearSettingsEnv(t)
+	tests := map[string]string{
+		"exit status": "exec:echo hunter2; exit 3",
+		"no output":   "exec:true --token=hunter2",
+	}
+	for name, value := range tests {
+		t.Setenv("KAFKA_TLS_KEY_PASSWORD", value)
+		_, err := getSecret("KAFKA_TLS_KEY_PASSWORD")
+		if err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+		if strings.Contains(err.Error(), "hunter2") {
+			t.Fatalf("%s: error %q leaks the secret", name, err)
+		}
+	}
+}
+
+func TestNewConfigResolvesSecrets(t *testing.T) {
+	clearSettingsEnv(t)
+	t.Setenv("KAFKA_TLS_ENABLED", "false")
+	t.Setenv("KAFKA_SASL_MECHANISM", SASLScramSHA512)
+	t.Setenv("KAFKA_SASL_USERNAME", "app")
+	t.Setenv("KAFKA_SASL_PASSWORD", "env:TEST_SCRAM_PASSWORD")
+	t.Setenv("TEST_SCRAM_PASSWORD", "s3cret")
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password, _ := cfg.readSASLPassword(); password != "s3cret" {
+		t.Fatalf("password = %q", password)
+	}
+
+	// Registry credentials are only resolved when the registry client is created
+	t.Setenv("SCHEMA_REGISTRY_URL", "http://registry:8081")
+	t.Setenv("SCHEMA_REGISTRY_USERNAME", "app")
+	t.Setenv("SCHEMA_REGISTRY_PASSWORD", "env:TEST_UNSET_SECRET")
+	cfg, err = NewConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.NewSchemaRegistry(); err == nil || !strings.Contains(err.Error(), "SCHEMA_REGISTRY_PASSWORD") {
+		t.Fatalf("expected a registry password error, got %v", err)
+	}
+	t.Setenv("TEST_UNSET_SECRET", "s3cret")
+	if _, err := cfg.NewSchemaRegistry(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetSecretRejectsDotEnvReferences(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the helper below needs sh")
+	}
+	clearSettingsEnv(t)
+	marker := filepath.Join(t.TempDir(), "ran")
+	loadTestDotEnv(t, "KAFKA_SASL_PASSWORD=exec:touch "+marker+"\n"+
+		"KAFKA_SASL_USERNAME=env:HOME\n"+
+		"KAFKA_TLS_KEY_PASSWORD=plain\n"+
+		"SCHEMA_REGISTRY_URL=http://localhost:8081\n"+
+		"SCHEMA_REGISTRY_BEARER_TOKEN=file://"+marker+"\n")
+
+	for _, key := range []string{"KAFKA_SASL_PASSWORD", "KAFKA_SASL_USERNAME"} {
+		if _, err := getSecret(key); err == nil || !strings.Contains(err.Error(), "not allowed in .env") {
+			t.Fatalf("%s: expected a .env error, got %v", key, err)
+		}
+	}
+	if secret, err := getSecret("KAFKA_TLS_KEY_PASSWORD"); err != nil || secret != "plain" {
+		t.Fatalf("plain .env secret = %q, %v", secret, err)
+	}
+	cfg := &Config{SchemaRegistryURL: getSetting("SCHEMA_REGISTRY_URL"), SchemaRegistryToken: getSetting("SCHEMA_REGISTRY_BEARER_TOKEN")}
+	if _, err := cfg.NewSchemaRegistry(); err == nil || !strings.Contains(err.Error(), "not allowed in .env") {
+		t.Fatalf("registry: expected a .env error, got %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("the exec: reference of .env ran: %v", err)
+	}
+
+	// An exported variable may still hold a reference
+	t.Setenv("KAFKA_SASL_USERNAME", "env:TEST_EXPORTED_USER")
+	t.Setenv("TEST_EXPORTED_USER", "app")
+	if secret, err := getSecret("KAFKA_SASL_USERNAME"); err != nil || secret != "app" {
+		t.Fatalf("exported reference = %q, %v", secret, err)
+	}
+}
@@ -0,0 +1,135 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// wireMagicByte starts every key or value in the Confluent wire format:
+// magic byte, 4-byte big endian schema ID, then the serialized data
+const wireMagicByte = 0
+
+// ParseWireFormat splits data in the Confluent wire format into its schema ID
+// and payload. ok is false when data is not in the wire format.
+func ParseWireFormat(data []byte) (id int, payload []byte, ok bool) {
+	if len(data) < 5 || data[0] != wireMagicByte {
+		return 0, nil, false
+	}
+	id = int(binary.BigEndian.Uint32(data[1:5]))
+	if id <= 0 {
+		return 0, nil, false
+	}
+	return id, data[5:], true
+}
+
+// AppendWireFormat prefixes payload with the magic byte and schema ID
+func AppendWireFormat(id int, payload []byte) []byte {
+	out := make([]byte, 5, 5+len(payload))
+	out[0] = wireMagicByte
+	binary.BigEndian.PutUint32(out[1:5], uint32(id))
+	return append(out, payload...)
+}
+
+// Errors of Decode that fetching the schema again won't fix
+var (
+	// ErrInvalidSchema is returned for a schema that can't be parsed or is of an unsupported type
+	ErrInvalidSchema = errors.New("invalid schema")
+	// ErrUndecodable is returned for data that doesn't match its schema
+	ErrUndecodable = errors.New("failed to decode data")
+)
+
+// schemaCodec converts between the serialized payload of one schema and JSON.
+// The message type is the full name of a Protobuf message, empty for other schema types.
+type schemaCodec interface {
+	decode(payload []byte) (data []byte, messageType string, err error)
+	encode(data []byte, messageType string) ([]byte, error)
+}
+
+// Decoded is data in the Confluent wire format converted to JSON
+type Decoded struct {
+	Data     []byte
+	SchemaID int
+	// MessageType is the full name of the Protobuf message, e.g. shop.Order,
+	// empty for other schema types
+	MessageType string
+}
+
+// Decode converts data in the Confluent wire format to JSON, along with the
+// schema ID it was written with. Avro, Protobuf and JSON Schema are supported.
+// The schema ID is set on errors once it has been read.
+func (r *SchemaRegistry) Decode(ctx context.Context, data []byte) (Decoded, error) {
+	id, payload, ok := ParseWireFormat(data)
+	if !ok {
+		return Decoded{}, fmt.Errorf("data is not in the schema registry wire format")
+	}
+	codec, err := r.codec(ctx, id)
+	if err != nil {
+		return Decoded{SchemaID: id}, err
+	}
+	decoded, messageType, err := codec.decode(payload)
+	if err != nil {
+		return Decoded{SchemaID: id}, fmt.Errorf("%w with schema %d: %w", ErrUndecodable, id, err)
+	}
+	return Decoded{Data: decoded, SchemaID: id, MessageType: messageType}, nil
+}
+
+// Encode serializes the JSON document data with schema and returns it in the wire format.
+// messageType selects the Protobuf message by full name, the first message of the schema
+// when empty; it can't be set for other schema types.
+func (r *SchemaRegistry) Encode(ctx context.Context, schema *Schema, data []byte, messageType string) ([]byte, error) {
+	if schema.ID <= 0 {
+		return nil, fmt.Errorf("schema has no ID")
+	}
+	codec, err := r.codec(ctx, schema.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := codec.(*protobufCodec); !ok && messageType != "" {
+		return nil, fmt.Errorf("message type %s is only supported with Protobuf schemas, schema %d is not", messageType, schema.ID)
+	}
+	payload, err := codec.encode(data, messageType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode data with schema %d: %w", schema.ID, err)
+	}
+	return AppendWireFormat(schema.ID, payload), nil
+}
+
+// codec returns the cached codec of schema id, building it on first use
+func (r *SchemaRegistry) codec(ctx context.Context, id int) (schemaCodec, error) {
+	r.mu.Lock()
+	codec, ok := r.codecs[id]
+	r.mu.Unlock()
+	if ok {
+		return codec, nil
+	}
+
+	schema, err := r.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.resolveReferences(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	switch schema.Type() {
+	case SchemaTypeAvro:
+		codec, err = newAvroCodec(schema, refs)
+	case SchemaTypeProtobuf:
+		codec, err = newProtobufCodec(schema, refs)
+	case SchemaTypeJSON:
+		codec, err = newJSONSchemaCodec(schema, refs)
+	default:
+		err = fmt.Errorf("unsupported schema type %q", schema.SchemaType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w %d: %w", ErrInvalidSchema, id, err)
+	}
+
+	r.mu.Lock()
+	r.codecs[id] = codec
+	r.mu.Unlock()
+	return codec, nil
+}
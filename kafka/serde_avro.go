@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// avroCodec reads and writes Avro binary data as standard JSON: unions are
+// not wrapped in {"type": value} objects
+type avroCodec struct {
+	codec *goavro.Codec
+}
+
+func newAvroCodec(schema *Schema, refs map[string]*Schema) (*avroCodec, error) {
+	spec := schema.Schema
+	if len(refs) > 0 {
+		inlined, err := inlineAvroReferences(spec, refs)
+		if err != nil {
+			return nil, err
+		}
+		spec = inlined
+	}
+
+	codec, err := goavro.NewCodecForStandardJSONFull(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &avroCodec{codec: codec}, nil
+}
+
+func (c *avroCodec) decode(payload []byte) ([]byte, string, error) {
+	native, _, err := c.codec.NativeFromBinary(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	text, err := c.codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, "", err
+	}
+	// goavro writes record fields in map order, sort them for a stable output
+	v, err := decodeJSONNumbers(text)
+	if err != nil {
+		return nil, "", err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, "", err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), "", nil
+}
+
+func (c *avroCodec) encode(data []byte, _ string) ([]byte, error) {
+	native, _, err := c.codec.NativeFromTextual(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.codec.BinaryFromNative(nil, native)
+}
+
+// inlineAvroReferences replaces the first use of each referenced type name by
+// its definition, since goavro only parses a single schema document
+func inlineAvroReferences(spec string, refs map[string]*Schema) (string, error) {
+	parsed := make(map[string]any, len(refs))
+	for name, ref := range refs {
+		v, err := decodeJSONNumbers([]byte(ref.Schema))
+		if err != nil {
+			return "", fmt.Errorf("invalid referenced schema %s: %w", name, err)
+		}
+		parsed[name] = v
+	}
+	root, err := decodeJSONNumbers([]byte(spec))
+	if err != nil {
+		return "", err
+	}
+
+	defined := make(map[string]bool)
+	var inline func(v any) any
+	inline = func(v any) any {
+		switch t := v.(type) {
+		case string:
+			if def, ok := parsed[t]; ok && !defined[t] {
+				defined[t] = true
+				return inline(def)
+			}
+			return t
+		case []any:
+			for i := range t {
+				t[i] = inline(t[i])
+			}
+			return t
+		case map[string]any:
+			for _, key := range []string{"type", "items", "values"} {
+				if field, ok := t[key]; ok {
+					t[key] = inline(field)
+				}
+			}
+			if fields, ok := t["fields"].([]any); ok {
+				for _, f := range fields {
+					if field, ok := f.(map[string]any); ok {
+						field["type"] = inline(field["type"])
+					}
+				}
+			}
+			return t
+		default:
+			return t
+		}
+	}
+
+	out, err := json.Marshal(inline(root))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// decodeJSONNumbers decodes data keeping numbers as json.Number
+func decodeJSONNumbers(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// jsonSchemaBaseURL is the base against which the schema and its references are resolved
+const jsonSchemaBaseURL = "https://schema-registry.local/schemas/"
+
+// jsonSchemaCodec validates JSON documents against a JSON Schema. The payload
+// already is JSON, so decoding returns it unchanged.
+type jsonSchemaCodec struct {
+	schema *jsonschema.Schema
+}
+
+func newJSONSchemaCodec(schema *Schema, refs map[string]*Schema) (*jsonSchemaCodec, error) {
+	base, _ := url.Parse(jsonSchemaBaseURL)
+	compiler := jsonschema.NewCompiler()
+	// Only the registered schemas are available, nothing is fetched from elsewhere
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("%s is not a reference of the schema", s)
+	}
+
+	mainURL := base.JoinPath("schema.json").String()
+	if err := compiler.AddResource(mainURL, strings.NewReader(schema.Schema)); err != nil {
+		return nil, err
+	}
+	for name, ref := range refs {
+		refURL, err := base.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference name %q: %w", name, err)
+		}
+		if err := compiler.AddResource(refURL.String(), strings.NewReader(ref.Schema)); err != nil {
+			return nil, fmt.Errorf("invalid referenced schema %s: %w", name, err)
+		}
+	}
+
+	compiled, err := compiler.Compile(mainURL)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonSchemaCodec{schema: compiled}, nil
+}
+
+func (c *jsonSchemaCodec) decode(payload []byte) ([]byte, string, error) {
+	if !json.Valid(payload) {
+		return nil, "", fmt.Errorf("payload is not valid JSON")
+	}
+	return payload, "", nil
+}
+
+func (c *jsonSchemaCodec) encode(data []byte, _ string) ([]byte, error) {
+	v, err := decodeJSONNumbers(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := c.schema.Validate(v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
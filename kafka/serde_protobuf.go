@@ -0,0 +1,166 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protobufSchemaFile is the file name given to the registered schema when compiling it
+const protobufSchemaFile = "schema.proto"
+
+// protobufCodec reads and writes Protobuf messages of one schema. The payload
+// starts with the indexes of the message type in the schema, as varints.
+type protobufCodec struct {
+	file protoreflect.FileDescriptor
+}
+
+func newProtobufCodec(schema *Schema, refs map[string]*Schema) (*protobufCodec, error) {
+	sources := map[string]string{protobufSchemaFile: schema.Schema}
+	for name, ref := range refs {
+		sources[name] = ref.Schema
+	}
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), protobufSchemaFile)
+	if err != nil {
+		return nil, err
+	}
+	if files[0].Messages().Len() == 0 {
+		return nil, fmt.Errorf("schema defines no message")
+	}
+	return &protobufCodec{file: files[0]}, nil
+}
+
+func (c *protobufCodec) decode(payload []byte) ([]byte, string, error) {
+	indexes, n, err := readMessageIndexes(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	desc, err := c.message(indexes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	msg := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(payload[n:], msg); err != nil {
+		return nil, "", err
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	return data, string(desc.FullName()), err
+}
+
+// encode writes data as the message type named messageType, or as the first
+// message of the schema when empty
+func (c *protobufCodec) encode(data []byte, messageType string) ([]byte, error) {
+	desc, indexes := c.file.Messages().Get(0), []int{0}
+	if messageType != "" {
+		var err error
+		if desc, indexes, err = c.findMessage(messageType); err != nil {
+			return nil, err
+		}
+	}
+
+	msg := dynamicpb.NewMessage(desc)
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return append(appendMessageIndexes(nil, indexes), payload...), nil
+}
+
+// message returns the message type at indexes: the index of a top level
+// message followed by the indexes of nested messages
+func (c *protobufCodec) message(indexes []int) (protoreflect.MessageDescriptor, error) {
+	messages := c.file.Messages()
+	var desc protoreflect.MessageDescriptor
+	for _, i := range indexes {
+		if i < 0 || i >= messages.Len() {
+			return nil, fmt.Errorf("message index %v not found in schema", indexes)
+		}
+		desc = messages.Get(i)
+		messages = desc.Messages()
+	}
+	return desc, nil
+}
+
+// findMessage returns the message type named name, with or without the
+// package of the schema, and its indexes
+func (c *protobufCodec) findMessage(name string) (protoreflect.MessageDescriptor, []int, error) {
+	fullName := protoreflect.FullName(name)
+	if pkg := string(c.file.Package()); pkg != "" && !strings.HasPrefix(name, pkg+".") {
+		fullName = protoreflect.FullName(pkg + "." + name)
+	}
+
+	var find func(messages protoreflect.MessageDescriptors, parents []int) (protoreflect.MessageDescriptor, []int)
+	find = func(messages protoreflect.MessageDescriptors, parents []int) (protoreflect.MessageDescriptor, []int) {
+		for i := 0; i < messages.Len(); i++ {
+			desc := messages.Get(i)
+			indexes := append(slices.Clip(parents), i)
+			if desc.FullName() == fullName {
+				return desc, indexes
+			}
+			if nested, nestedIndexes := find(desc.Messages(), indexes); nested != nil {
+				return nested, nestedIndexes
+			}
+		}
+		return nil, nil
+	}
+	if desc, indexes := find(c.file.Messages(), nil); desc != nil {
+		return desc, indexes, nil
+	}
+	return nil, nil, fmt.Errorf("message type %s not found in schema", name)
+}
+
+// appendMessageIndexes writes indexes as read by readMessageIndexes. The first
+// message is written as a single 0 rather than [1, 0].
+func appendMessageIndexes(b []byte, indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return append(b, 0)
+	}
+	b = binary.AppendVarint(b, int64(len(indexes)))
+	for _, i := range indexes {
+		b = binary.AppendVarint(b, int64(i))
+	}
+	return b
+}
+
+// readMessageIndexes reads the message indexes at the start of payload and
+// returns them with the number of bytes read. An empty list means [0].
+func readMessageIndexes(payload []byte) ([]int, int, error) {
+	errInvalid := errors.New("invalid protobuf message indexes")
+
+	count, n := binary.Varint(payload)
+	if n <= 0 || count < 0 || count > int64(len(payload)) {
+		return nil, 0, errInvalid
+	}
+	if count == 0 {
+		return []int{0}, n, nil
+	}
+
+	indexes := make([]int, count)
+	for i := range indexes {
+		index, m := binary.Varint(payload[n:])
+		if m <= 0 {
+			return nil, 0, errInvalid
+		}
+		indexes[i] = int(index)
+		n += m
+	}
+	return indexes, n, nil
+}
@@ -0,0 +1,241 @@
+package kafka
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// clientCertificate is the mTLS client certificate, loaded from PEM files or a
+// PKCS#12 bundle. With reload enabled it is reloaded when its files change.
+type clientCertificate struct {
+	certFile   string
+	keyFile    string
+	pkcs12File string
+	password   string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modTimes []time.Time
+}
+
+// configureClientCertificate adds the client certificate configured with
+// KAFKA_TLS_CERT_FILE/KAFKA_TLS_KEY_FILE or KAFKA_TLS_PKCS12_FILE to tlsCfg
+func configureClientCertificate(tlsCfg *tls.Config) error {
+	src := &clientCertificate{
+		certFile:   expandHome(strings.TrimSpace(getSetting("KAFKA_TLS_CERT_FILE"))),
+		keyFile:    expandHome(strings.TrimSpace(getSetting("KAFKA_TLS_KEY_FILE"))),
+		pkcs12File: expandHome(strings.TrimSpace(getSetting("KAFKA_TLS_PKCS12_FILE"))),
+	}
+	var err error
+	if src.password, err = getSecret("KAFKA_TLS_KEY_PASSWORD"); err != nil {
+		return err
+	}
+	switch {
+	case src.pkcs12File != "" && (src.certFile != "" || src.keyFile != ""):
+		return fmt.Errorf("set either KAFKA_TLS_PKCS12_FILE or KAFKA_TLS_CERT_FILE/KAFKA_TLS_KEY_FILE, not both")
+	case src.pkcs12File == "" && src.certFile == "" && src.keyFile == "":
+		return nil
+	case src.pkcs12File == "" && src.certFile == "":
+		return fmt.Errorf("KAFKA_TLS_CERT_FILE is required with KAFKA_TLS_KEY_FILE")
+	case src.pkcs12File == "" && src.keyFile == "":
+		// The key is in the certificate file
+		src.keyFile = src.certFile
+	}
+
+	cert, err := src.load()
+	if err != nil {
+		return err
+	}
+	if reload, _ := lookupEnvBool("KAFKA_TLS_RELOAD"); !reload {
+		tlsCfg.Certificates = []tls.Certificate{*cert}
+		return nil
+	}
+	src.cert = cert
+	src.modTimes = src.stat()
+	tlsCfg.GetClientCertificate = src.getClientCertificate
+	return nil
+}
+
+// getClientCertificate returns the certificate, reloaded first if its files changed.
+// A failed reload, e.g. while the files are being replaced, keeps the previous one.
+func (c *clientCertificate) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if modTimes := c.stat(); !equalTimes(modTimes, c.modTimes) {
+		if cert, err := c.load(); err == nil {
+			c.cert = cert
+			c.modTimes = modTimes
+		}
+	}
+	return c.cert, nil
+}
+
+// files returns the files the certificate is loaded from
+func (c *clientCertificate) files() []string {
+	if c.pkcs12File != "" {
+		return []string{c.pkcs12File}
+	}
+	return []string{c.certFile, c.keyFile}
+}
+
+// stat returns the modification times of the certificate files, zero for missing files
+func (c *clientCertificate) stat() []time.Time {
+	files := c.files()
+	modTimes := make([]time.Time, len(files))
+	for i, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			modTimes[i] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+func (c *clientCertificate) load() (*tls.Certificate, error) {
+	if c.pkcs12File != "" {
+		return loadPKCS12(c.pkcs12File, c.password)
+	}
+	return loadPEMKeyPair(c.certFile, c.keyFile, c.password)
+}
+
+// loadPKCS12 loads the key, certificate and chain of a PKCS#12 bundle
+func loadPKCS12(path, password string) (*tls.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KAFKA_TLS_PKCS12_FILE %q: %w", path, err)
+	}
+	key, leaf, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PKCS#12 bundle %q: %w", path, err)
+	}
+	chain := [][]byte{leaf.Raw}
+	for _, ca := range caCerts {
+		chain = append(chain, ca.Raw)
+	}
+	return newCertificate(chain, key, path)
+}
+
+// loadPEMKeyPair loads a PEM certificate chain and its private key, which may
+// be encrypted with password (PKCS#8 or legacy OpenSSL encryption)
+func loadPEMKeyPair(certFile, keyFile, password string) (*tls.Certificate, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KAFKA_TLS_CERT_FILE %q: %w", certFile, err)
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KAFKA_TLS_KEY_FILE %q: %w", keyFile, err)
+	}
+
+	var chain [][]byte
+	for rest := certPEM; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, block.Bytes)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificate found in %q", certFile)
+	}
+
+	key, err := parsePEMPrivateKey(keyPEM, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load private key %q: %w", keyFile, err)
+	}
+	return newCertificate(chain, key, certFile)
+}
+
+// parsePEMPrivateKey returns the first private key of a PEM file, decrypted with password
+func parsePEMPrivateKey(data []byte, password string) (crypto.PrivateKey, error) {
+	for rest := data; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+
+		switch {
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			if password == "" {
+				return nil, errors.New("the key is encrypted, set KAFKA_TLS_KEY_PASSWORD")
+			}
+			key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt the key (wrong KAFKA_TLS_KEY_PASSWORD?): %w", err)
+			}
+			return key, nil
+		case x509.IsEncryptedPEMBlock(block): //nolint:staticcheck // legacy OpenSSL encrypted keys are still common
+			if password == "" {
+				return nil, errors.New("the key is encrypted, set KAFKA_TLS_KEY_PASSWORD")
+			}
+			der, err := x509.DecryptPEMBlock(block, []byte(password)) //nolint:staticcheck // see above
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt the key (wrong KAFKA_TLS_KEY_PASSWORD?): %w", err)
+			}
+			return parsePrivateKeyDER(der)
+		default:
+			return parsePrivateKeyDER(block.Bytes)
+		}
+	}
+	return nil, errors.New("no private key found")
+}
+
+// parsePrivateKeyDER parses a PKCS#1, PKCS#8 or SEC 1 (EC) private key
+func parsePrivateKeyDER(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key format")
+}
+
+// newCertificate builds a TLS certificate from a chain, leaf first, and checks
+// that key belongs to the leaf
+func newCertificate(chain [][]byte, key crypto.PrivateKey, path string) (*tls.Certificate, error) {
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the certificate in %q: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T in %q", key, path)
+	}
+	public, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(signer.Public()) {
+		return nil, fmt.Errorf("the private key does not match the certificate in %q", path)
+	}
+	return &tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: leaf}, nil
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
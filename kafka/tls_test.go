@@ -0,0 +1,214 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// newTestClientCert returns a self-signed client certificate and its key
+func newTestClientCert(t *testing.T, commonName string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writePEM(t *testing.T, path string, blocks ...*pem.Block) string {
+	t.Helper()
+	var data []byte
+	for _, b := range blocks {
+		data = append(data, pem.EncodeToMemory(b)...)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPEMKeyPairKeyFormats(t *testing.T) {
+	dir := t.TempDir()
+	cert, key := newTestClientCert(t, "app")
+	certFile := writePEM(t, filepath.Join(dir, "user.crt"), &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+
+	plainDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8DER, err := pkcs8.ConvertPrivateKeyToPKCS8(key, []byte("s3cret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", plainDER, []byte("s3cret"), x509.PEMCipherAES256) //nolint:staticcheck // legacy format under test
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := map[string]*pem.Block{
+		"plain":     {Type: "EC PRIVATE KEY", Bytes: plainDER},
+		"pkcs8-enc": {Type: "ENCRYPTED PRIVATE KEY", Bytes: pkcs8DER},
+		"legacy":    legacy,
+	}
+	for name, block := range keys {
+		keyFile := writePEM(t, filepath.Join(dir, name+".key"), block)
+		tlsCert, err := loadPEMKeyPair(certFile, keyFile, "s3cret")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if tlsCert.Leaf.Subject.CommonName != "app" {
+			t.Fatalf("%s: unexpected leaf %v", name, tlsCert.Leaf.Subject)
+		}
+	}
+
+	encrypted := filepath.Join(dir, "pkcs8-enc.key")
+	if _, err := loadPEMKeyPair(certFile, encrypted, "wrong"); err == nil || !strings.Contains(err.Error(), "wrong KAFKA_TLS_KEY_PASSWORD") {
+		t.Fatalf("expected a wrong password error, got %v", err)
+	}
+	if _, err := loadPEMKeyPair(certFile, encrypted, ""); err == nil || !strings.Contains(err.Error(), "set KAFKA_TLS_KEY_PASSWORD") {
+		t.Fatalf("expected a missing password error, got %v", err)
+	}
+
+	// Certificate and key in one file
+	combined := writePEM(t, filepath.Join(dir, "combined.pem"), &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}, keys["plain"])
+	if _, err := loadPEMKeyPair(combined, combined, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherKey := newTestClientCert(t, "other")
+	otherDER, _ := x509.MarshalECPrivateKey(otherKey)
+	other := writePEM(t, filepath.Join(dir, "other.key"), &pem.Block{Type: "EC PRIVATE KEY", Bytes: otherDER})
+	if _, err := loadPEMKeyPair(certFile, other, ""); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected a key mismatch error, got %v", err)
+	}
+}
+
+func TestLoadPKCS12(t *testing.T) {
+	cert, key := newTestClientCert(t, "strimzi-user")
+	data, err := pkcs12.Modern.Encode(key, cert, nil, "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "user.p12")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tlsCert, err := loadPKCS12(path, "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsCert.Leaf.Subject.CommonName != "strimzi-user" {
+		t.Fatalf("unexpected leaf %v", tlsCert.Leaf.Subject)
+	}
+	if _, err := loadPKCS12(path, "wrong"); err == nil {
+		t.Fatalf("expected a wrong password error")
+	}
+}
+
+func TestBuildTLSConfigFromEnvClientCertificate(t *testing.T) {
+	clearSettingsEnv(t)
+	dir := t.TempDir()
+	cert, key := newTestClientCert(t, "app")
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	t.Setenv("KAFKA_TLS_CERT_FILE", writePEM(t, filepath.Join(dir, "user.crt"), &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	t.Setenv("KAFKA_TLS_KEY_FILE", writePEM(t, filepath.Join(dir, "user.key"), &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	t.Setenv("KAFKA_TLS_SERVER_NAME", "kafka.internal")
+
+	cfg, err := buildTLSConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Certificates) != 1 || cfg.GetClientCertificate != nil || cfg.ServerName != "kafka.internal" {
+		t.Fatalf("unexpected TLS config: %d certificates, server name %q", len(cfg.Certificates), cfg.ServerName)
+	}
+
+	t.Setenv("KAFKA_TLS_PKCS12_FILE", filepath.Join(dir, "user.p12"))
+	if _, err := buildTLSConfigFromEnv(); err == nil {
+		t.Fatalf("expected an error for PEM and PKCS#12 together")
+	}
+}
+
+func TestClientCertificateReload(t *testing.T) {
+	clearSettingsEnv(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	write := func(commonName string, modTime time.Time) {
+		cert, key := newTestClientCert(t, commonName)
+		keyDER, _ := x509.MarshalECPrivateKey(key)
+		writePEM(t, certFile, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		writePEM(t, keyFile, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+		for _, f := range []string{certFile, keyFile} {
+			if err := os.Chtimes(f, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	write("first", time.Now().Add(-time.Minute))
+	t.Setenv("KAFKA_TLS_CERT_FILE", certFile)
+	t.Setenv("KAFKA_TLS_KEY_FILE", keyFile)
+	t.Setenv("KAFKA_TLS_RELOAD", "true")
+
+	cfg, err := buildTLSConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GetClientCertificate == nil || len(cfg.Certificates) != 0 {
+		t.Fatalf("expected GetClientCertificate with reload enabled")
+	}
+	commonName := func() string {
+		cert, err := cfg.GetClientCertificate(&tls.CertificateRequestInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert.Leaf.Subject.CommonName
+	}
+	if cn := commonName(); cn != "first" {
+		t.Fatalf("certificate = %s, want first", cn)
+	}
+
+	write("second", time.Now())
+	if cn := commonName(); cn != "second" {
+		t.Fatalf("certificate = %s after rotation, want second", cn)
+	}
+
+	// A half-written rotation keeps the previous certificate
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(keyFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if cn := commonName(); cn != "second" {
+		t.Fatalf("certificate = %s after a failed reload, want second", cn)
+	}
+}